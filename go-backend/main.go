@@ -1,9 +1,26 @@
 package main
 
 import (
+	"log"
+
+	asynqutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/asynq"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/handlers"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	"github.com/gin-gonic/gin"
 )
 
 func main() {
+	config.Load()
+	redisutil.ConnectToRedis(config.ConfigGlobal)
+	asynqutil.ConnectAsynqClient(config.ConfigGlobal)
+	defer asynqutil.AsynqClient.Close()
+
 	gin.SetMode(gin.ReleaseMode)
+	router := gin.Default()
+	handlers.RegisterRoutes(router)
+
+	if err := router.Run(":" + config.ConfigGlobal.Port); err != nil {
+		log.Fatalf("❌ Backend server stopped: %v", err)
+	}
 }
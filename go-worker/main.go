@@ -1,8 +1,20 @@
 package main
 
 import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
 	asynqutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/asynq"
 	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/notify"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
 	syncutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks/sync"
@@ -19,13 +31,25 @@ func main() {
 		redisOpt,
 		asynq.Config{
 			Concurrency: 10,
-			Queues: map[string]int{
-				"default": 10,
-			},
+			// critical and default plus any queue named in the schedule config
+			Queues: tasks.ScheduleQueues(),
+			// Email backs off exponentially, everything else keeps asynq's default
+			RetryDelayFunc: tasks.RetryDelay,
 		},
 	)
 
+	asyncClient := asynq.NewClient(redisOpt)
+	defer asyncClient.Close()
+
+	if err := tasks.RegisterWorkflow(tasks.FullSyncWorkflow()); err != nil {
+		log.Fatalf("❌ Invalid full sync workflow: %v", err)
+	}
+
 	mux := asynq.NewServeMux()
+	// Record a run summary for every sync:* task attempt
+	mux.Use(syncutil.RecordRuns)
+	// Enqueue workflow dependents when a step succeeds, apply the failure policy when it gives up
+	mux.Use(tasks.WorkflowMiddleware(asyncClient))
 
 	// Register all task handlers (needed for workers to process individual tasks)
 	mux.HandleFunc(tasks.SyncProducts, syncutil.HandleSyncProductsTask)
@@ -34,22 +58,104 @@ func main() {
 	mux.HandleFunc(tasks.SyncCustomerStatements, syncutil.HandleSyncCustomerStatementsTask)
 	mux.HandleFunc(tasks.SyncOrders, syncutil.HandleSyncOrdersTask)
 	mux.HandleFunc(tasks.SyncInvoicesAndLines, syncutil.HandleSyncInvoicesAndLinesTask)
+	mux.HandleFunc(tasks.SyncReconcile, syncutil.HandleSyncReconcileTask)
+	mux.HandleFunc(tasks.SyncStock, syncutil.HandleSyncStockTask)
+	mux.HandleFunc(tasks.SyncRecords, syncutil.HandleSyncRecordsTask)
+	mux.HandleFunc(tasks.SyncCustomerRefresh, syncutil.HandleCustomerRefreshTask)
+	mux.HandleFunc(tasks.SyncPayments, syncutil.HandleSyncPaymentsTask)
+	mux.HandleFunc(tasks.SyncDeliveries, syncutil.HandleSyncDeliveriesTask)
+	mux.HandleFunc(tasks.NotifyEmail, notify.HandleEmailTask)
 
 	// Register orchestration handler - this will orchestrate all sync tasks
 	mux.HandleFunc(tasks.OrchestrateFullSync, syncutil.HandleOrchestrateFullSyncTask)
 
-	scheduler := asynq.NewScheduler(redisOpt, nil)
-	// Schedule orchestration task instead of individual tasks
-	scheduler.Register("0 * * * *", tasks.OrchestrateFullSyncTask())
+	// Prometheus metrics: sync, Odoo, Typesense and Redis collectors plus asynq queue stats
+	metrics.RegisterQueueCollector(redisOpt)
+	syncutil.RestoreSyncMetrics(context.Background())
+
+	// Only the elected leader runs the scheduler, so N workers still mean one sync per hour
+	elector := redisutil.NewElector(tasks.SchedulerLeaderKey, workerID(), schedulerLeaseTTL)
+	go serveMetrics(elector)
+	go elector.Run(context.Background(), func(ctx context.Context) {
+		// Only the leader applies Typesense schema changes, before it schedules any sync
+		syncutil.MigrateTypesenseSchemas(ctx, asyncClient)
+		runScheduler(ctx, redisOpt, asyncClient)
+	})
+
+	asyncServer.Run(mux)
+}
+
+// schedulerLeaseTTL bounds how long the scheduler stays leaderless after its worker dies
+const schedulerLeaseTTL = 30 * time.Second
 
-	go func() {
-		scheduler.Run()
-	}()
+var startupSyncEnqueued bool
+
+// runScheduler runs the cron scheduler for as long as this worker is leader
+func runScheduler(ctx context.Context, redisOpt asynq.RedisConnOpt, asyncClient *asynq.Client) {
+	metrics.SchedulerLeader.Set(1)
+	defer metrics.SchedulerLeader.Set(0)
+
+	// Per-task cron schedules from go-worker/schedules.yaml / SYNC_SCHEDULES,
+	// re-read every minute so edits apply without a restart
+	scheduler, err := asynq.NewPeriodicTaskManager(asynq.PeriodicTaskManagerOpts{
+		RedisConnOpt:               redisOpt,
+		PeriodicTaskConfigProvider: tasks.ScheduleProvider{},
+		SyncInterval:               time.Minute,
+	})
+	if err != nil {
+		log.Printf("❌ Scheduler failed to start: %v", err)
+		return
+	}
+	if err := scheduler.Start(); err != nil {
+		log.Printf("❌ Scheduler failed to start: %v", err)
+		return
+	}
 
 	// Enqueue orchestration task immediately on startup (optional)
-	asyncClient := asynq.NewClient(redisOpt)
-	asyncClient.Enqueue(tasks.OrchestrateFullSyncTask())
-	defer asyncClient.Close()
+	if !startupSyncEnqueued {
+		startupSyncEnqueued = true
+		asyncClient.Enqueue(tasks.OrchestrateFullSyncTask())
+	}
 
-	asyncServer.Run(mux)
+	<-ctx.Done()
+	scheduler.Shutdown()
+	log.Println("⏹️  Scheduler stopped, leadership given up")
+}
+
+// workerID identifies this process in leader election and health output
+func workerID() string {
+	hostname, _ := os.Hostname()
+	suffix := make([]byte, 3)
+	rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+}
+
+func serveMetrics(elector *redisutil.Elector) {
+	port := config.ConfigGlobal.MetricsPort
+	if port == "" {
+		port = "9091"
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+		leader, err := redisutil.LockOwner(r.Context(), tasks.SchedulerLeaderKey)
+		status := http.StatusOK
+		health := map[string]any{
+			"status":    "ok",
+			"worker_id": elector.ID(),
+			"is_leader": elector.IsLeader(),
+			"leader":    leader,
+		}
+		if err != nil {
+			status = http.StatusServiceUnavailable
+			health["status"] = "redis unavailable"
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(health)
+	})
+	log.Printf("📈 Serving worker metrics on :%s/metrics", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		log.Printf("❌ Metrics server stopped: %v", err)
+	}
 }
@@ -9,6 +9,14 @@ import (
 	"github.com/hibiken/asynq"
 )
 
+// AsynqClient lets the backend enqueue tasks for the worker
+var AsynqClient *asynq.Client
+
+// ConnectAsynqClient creates the shared asynq client
+func ConnectAsynqClient(config *config.Config) {
+	AsynqClient = asynq.NewClient(ConnectToAsyncq(config))
+}
+
 func ConnectToAsyncq(config *config.Config) *asynq.RedisClientOpt {
 	url, err := url.Parse(config.RedisUrl)
 	if err != nil {
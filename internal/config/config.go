@@ -19,6 +19,41 @@ type Config struct {
 	OdooDB        string
 	OdooUsername  string
 	OdooPassword  string
+	AdminAPIKey   string
+	// SyncRunRetentionDays is how long sync run records are kept (default 14)
+	SyncRunRetentionDays string
+	// MetricsPort is where go-worker serves /metrics (default 9091)
+	MetricsPort string
+	// SyncFailurePolicy is abort, continue (default) or retry_group
+	SyncFailurePolicy string
+	SyncGroupRetries  string
+	// SyncScheduleFile and SyncSchedules (inline YAML) configure per-task cron schedules
+	SyncScheduleFile string
+	SyncSchedules    string
+	// OdooWebhookSecret signs Odoo webhook calls; the webhook is closed without it
+	OdooWebhookSecret string
+	// WebhookDebounceSeconds batches webhook bursts per model (default 10)
+	WebhookDebounceSeconds string
+	// CustomerRefreshIntervalSeconds is the minimum gap between refreshes of one customer (default 60)
+	CustomerRefreshIntervalSeconds string
+	// Company details printed on statements and invoices; COMPANY_ADDRESS lines are "|" separated
+	CompanyName     string
+	CompanyAddress  string
+	CompanyPhone    string
+	CompanyEmail    string
+	CompanyVAT      string
+	CompanyLogoPath string
+	CompanyCurrency string
+	// Outgoing mail for the email outbox. SMTP_TLS is starttls (default), tls or none;
+	// point SMTP_HOST/SMTP_PORT at MailHog (localhost:1025, SMTP_TLS=none) to test locally
+	SMTPHost     string
+	SMTPPort     string
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     string
+	SMTPTLS      string
+	// How many months of customer payments sync:payments keeps (default 6)
+	PaymentsSyncMonths string
 }
 
 func Load() {
@@ -37,6 +72,36 @@ func Load() {
 		OdooDB:        getEnv("ODOO_DB"),
 		OdooUsername:  getEnv("ODOO_USERNAME"),
 		OdooPassword:  getEnv("ODOO_PASSWORD"),
+		AdminAPIKey:   getEnv("ADMIN_API_KEY"),
+
+		SyncRunRetentionDays: getEnv("SYNC_RUN_RETENTION_DAYS"),
+		MetricsPort:          getEnv("METRICS_PORT"),
+		SyncFailurePolicy:    getEnv("SYNC_FAILURE_POLICY"),
+		SyncGroupRetries:     getEnv("SYNC_GROUP_RETRIES"),
+		SyncScheduleFile:     getEnv("SYNC_SCHEDULE_FILE"),
+		SyncSchedules:        getEnv("SYNC_SCHEDULES"),
+
+		OdooWebhookSecret:      getEnv("ODOO_WEBHOOK_SECRET"),
+		WebhookDebounceSeconds: getEnv("WEBHOOK_DEBOUNCE_SECONDS"),
+
+		CustomerRefreshIntervalSeconds: getEnv("CUSTOMER_REFRESH_INTERVAL_SECONDS"),
+
+		CompanyName:     getEnv("COMPANY_NAME"),
+		CompanyAddress:  getEnv("COMPANY_ADDRESS"),
+		CompanyPhone:    getEnv("COMPANY_PHONE"),
+		CompanyEmail:    getEnv("COMPANY_EMAIL"),
+		CompanyVAT:      getEnv("COMPANY_VAT"),
+		CompanyLogoPath: getEnv("COMPANY_LOGO_PATH"),
+		CompanyCurrency: getEnv("COMPANY_CURRENCY"),
+
+		SMTPHost:     getEnv("SMTP_HOST"),
+		SMTPPort:     getEnv("SMTP_PORT"),
+		SMTPUsername: getEnv("SMTP_USERNAME"),
+		SMTPPassword: getEnv("SMTP_PASSWORD"),
+		SMTPFrom:     getEnv("SMTP_FROM"),
+		SMTPTLS:      getEnv("SMTP_TLS"),
+
+		PaymentsSyncMonths: getEnv("PAYMENTS_SYNC_MONTHS"),
 	}
 
 }
@@ -0,0 +1,70 @@
+package documents
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+)
+
+// Company is the letterhead printed on every document
+type Company struct {
+	Name     string
+	Address  []string
+	Phone    string
+	Email    string
+	VAT      string
+	LogoPath string
+	Currency string
+}
+
+// CompanyFromConfig reads the letterhead from COMPANY_* settings.
+// COMPANY_ADDRESS lines are separated by "|".
+func CompanyFromConfig() Company {
+	cfg := config.ConfigGlobal
+	company := Company{
+		Name:     cfg.CompanyName,
+		Phone:    cfg.CompanyPhone,
+		Email:    cfg.CompanyEmail,
+		VAT:      cfg.CompanyVAT,
+		LogoPath: cfg.CompanyLogoPath,
+		Currency: cfg.CompanyCurrency,
+	}
+	for _, line := range strings.Split(cfg.CompanyAddress, "|") {
+		if line = strings.TrimSpace(line); line != "" {
+			company.Address = append(company.Address, line)
+		}
+	}
+	return company
+}
+
+// contactLine joins phone, email and VAT number for the letterhead
+func (company Company) contactLine() string {
+	parts := []string{}
+	if company.Phone != "" {
+		parts = append(parts, "Tel "+company.Phone)
+	}
+	if company.Email != "" {
+		parts = append(parts, company.Email)
+	}
+	if company.VAT != "" {
+		parts = append(parts, "VAT "+company.VAT)
+	}
+	return strings.Join(parts, "  ·  ")
+}
+
+// Money formats an amount with thousands separators and the company currency
+func (company Company) Money(amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	cents := int64(math.Round(amount * 100))
+	whole := fmt.Sprintf("%d", cents/100)
+	for i := len(whole) - 3; i > 0; i -= 3 {
+		whole = whole[:i] + "," + whole[i:]
+	}
+	return fmt.Sprintf("%s%s%s.%02d", sign, company.Currency, whole, cents%100)
+}
@@ -0,0 +1,180 @@
+package documents
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/statements"
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrNotFound means the synced data for the document isn't in Redis
+var ErrNotFound = errors.New("document data not found")
+
+// Statement is dashboard:customer_statement:{id} as written by the statement sync
+type Statement struct {
+	PartnerID      int              `json:"partner_id"`
+	PartnerName    string           `json:"partner_name"`
+	OpeningBalance float64          `json:"opening_balance"`
+	ClosingBalance float64          `json:"closing_balance"`
+	Entries        []StatementEntry `json:"entries"`
+}
+
+type StatementEntry struct {
+	Journal        string  `json:"journal"`
+	InvoiceID      int     `json:"invoice_id"`
+	InvoiceName    string  `json:"invoice_name"`
+	InvoiceDate    string  `json:"invoice_date"`
+	Debit          float64 `json:"debit"`
+	Credit         float64 `json:"credit"`
+	RunningBalance float64 `json:"running_balance"`
+}
+
+// Invoice is the invoices:{id} hash plus its invoice_lines:{id}. Credit notes
+// (MoveType out_refund) carry negative amounts and the invoice they reverse.
+type Invoice struct {
+	ID           string
+	Name         string
+	MoveType     string
+	ReversedName string
+	InvoiceDate  string
+	PartnerName  string
+	Salesperson  string
+	PaymentState string
+	AmountTotal  float64
+	Lines        []InvoiceLine
+}
+
+type InvoiceLine struct {
+	Product    string  `json:"product"`
+	ProductID  int     `json:"product_id"`
+	Quantity   float64 `json:"quantity"`
+	PriceTotal float64 `json:"price_total"`
+}
+
+// LoadStatement reads the partner's synced 6 month statement
+func LoadStatement(ctx context.Context, partnerID int) (*Statement, error) {
+	raw, err := redisutil.RedisClient.Get(ctx, statements.DashboardKey(partnerID)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var statement Statement
+	if err := json.Unmarshal(raw, &statement); err != nil {
+		return nil, fmt.Errorf("failed to decode statement of partner %d: %w", partnerID, err)
+	}
+	return &statement, nil
+}
+
+// LoadInvoice reads a synced invoice header and its lines
+func LoadInvoice(ctx context.Context, invoiceID int) (*Invoice, error) {
+	header, err := redisutil.RedisClient.HGetAll(ctx, fmt.Sprintf("invoices:%d", invoiceID)).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(header) == 0 {
+		return nil, ErrNotFound
+	}
+
+	amountTotal, _ := strconv.ParseFloat(header["amount_total"], 64)
+	invoice := &Invoice{
+		ID:           header["id"],
+		Name:         header["name"],
+		MoveType:     header["move_type"],
+		ReversedName: header["reversed_entry_name"],
+		InvoiceDate:  header["invoice_date"],
+		PartnerName:  header["partner_name"],
+		Salesperson:  header["salesperson"],
+		PaymentState: header["payment_state"],
+		AmountTotal:  amountTotal,
+		Lines:        []InvoiceLine{},
+	}
+
+	raw, err := redisutil.RedisClient.Get(ctx, fmt.Sprintf("invoice_lines:%d", invoiceID)).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &invoice.Lines); err != nil {
+			return nil, fmt.Errorf("failed to decode lines of invoice %d: %w", invoiceID, err)
+		}
+	}
+	return invoice, nil
+}
+
+// Order is a synced sale order from the orders_page dataset plus its order_lines:{id}
+type Order struct {
+	ID              string      `json:"id"`
+	Name            string      `json:"name"`
+	PartnerID       int         `json:"partner_id"`
+	PartnerName     string      `json:"partner_name"`
+	DateOrder       string      `json:"date_order"`
+	ExpectedDate    string      `json:"expected_date"`
+	AmountTotal     float64     `json:"amount_total"`
+	DeliveryStatus  string      `json:"delivery_status"`
+	InvoiceStatus   string      `json:"invoice_status"`
+	AmountToInvoice float64     `json:"amount_to_invoice"`
+	Lines           []OrderLine `json:"lines"`
+}
+
+type OrderLine struct {
+	Product      string  `json:"product"`
+	ProductID    int     `json:"product_id"`
+	Quantity     float64 `json:"quantity"`
+	QtyDelivered float64 `json:"qty_delivered"`
+	PriceUnit    float64 `json:"price_unit"`
+	Discount     float64 `json:"discount"`
+	Subtotal     float64 `json:"subtotal"`
+}
+
+// LoadOrderRecord returns an order header from the current orders_page
+// generation with its lines under "lines" and its outgoing pickings under
+// "deliveries" (each empty until synced)
+func LoadOrderRecord(ctx context.Context, orderID int) (map[string]any, error) {
+	order, err := redisutil.FindRecord(ctx, "orders_page", strconv.Itoa(orderID))
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for field, key := range map[string]string{
+		"lines":      fmt.Sprintf("order_lines:%d", orderID),
+		"deliveries": fmt.Sprintf("deliveries:order:%d", orderID),
+	} {
+		values := []map[string]any{}
+		raw, err := redisutil.RedisClient.Get(ctx, key).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			return nil, err
+		}
+		if len(raw) > 0 {
+			if err := json.Unmarshal(raw, &values); err != nil {
+				return nil, fmt.Errorf("failed to decode %s: %w", key, err)
+			}
+		}
+		order[field] = values
+	}
+	return order, nil
+}
+
+// LoadOrder is LoadOrderRecord decoded for rendering
+func LoadOrder(ctx context.Context, orderID int) (*Order, error) {
+	record, err := LoadOrderRecord(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	raw, _ := json.Marshal(record)
+	var order Order
+	if err := json.Unmarshal(raw, &order); err != nil {
+		return nil, fmt.Errorf("failed to decode order %d: %w", orderID, err)
+	}
+	return &order, nil
+}
@@ -0,0 +1,137 @@
+package documents
+
+import (
+	"fmt"
+	"time"
+)
+
+// Document is a rendered-format-agnostic table with a title block, so one
+// statement or invoice feeds the PDF, CSV and XLSX renderers alike
+type Document struct {
+	Title    string
+	Filename string
+	// Details are label/value pairs printed under the title
+	Details [][2]string
+	Columns []Column
+	// Rows hold a string or a float64 per column
+	Rows [][]any
+	// Totals are label/amount pairs printed under the table
+	Totals []Total
+}
+
+type Column struct {
+	Header string
+	// Width is the PDF column width in mm
+	Width float64
+	// Numeric columns are right aligned; Money ones are also formatted as money
+	Numeric bool
+	Money   bool
+}
+
+type Total struct {
+	Label  string
+	Amount float64
+}
+
+// StatementDocument lays out a partner's synced statement
+func StatementDocument(statement *Statement) *Document {
+	doc := &Document{
+		Title:    "Customer Statement",
+		Filename: fmt.Sprintf("statement-%d", statement.PartnerID),
+		Details: [][2]string{
+			{"Customer", statement.PartnerName},
+			{"Customer ID", fmt.Sprintf("%d", statement.PartnerID)},
+			{"Generated", time.Now().Format("2006-01-02 15:04")},
+		},
+		Columns: []Column{
+			{Header: "Date", Width: 24},
+			{Header: "Journal", Width: 36},
+			{Header: "Reference", Width: 40},
+			{Header: "Debit", Width: 28, Numeric: true, Money: true},
+			{Header: "Credit", Width: 28, Numeric: true, Money: true},
+			{Header: "Balance", Width: 30, Numeric: true, Money: true},
+		},
+		Rows: [][]any{},
+		Totals: []Total{
+			{"Opening balance", statement.OpeningBalance},
+			{"Closing balance", statement.ClosingBalance},
+		},
+	}
+	if len(statement.Entries) > 0 {
+		first, last := statement.Entries[0].InvoiceDate, statement.Entries[len(statement.Entries)-1].InvoiceDate
+		doc.Details = append(doc.Details, [2]string{"Period", first + " to " + last})
+	}
+
+	doc.Rows = append(doc.Rows, []any{"", "", "Opening balance", "", "", statement.OpeningBalance})
+	for _, entry := range statement.Entries {
+		doc.Rows = append(doc.Rows, []any{
+			entry.InvoiceDate, entry.Journal, entry.InvoiceName,
+			entry.Debit, entry.Credit, entry.RunningBalance,
+		})
+	}
+	return doc
+}
+
+// InvoiceDocument lays out a synced invoice header and its lines
+func InvoiceDocument(invoice *Invoice) *Document {
+	title, filename, totalLabel := "Invoice Summary", "invoice-", "Invoice total"
+	if invoice.MoveType == "out_refund" {
+		title, filename, totalLabel = "Credit Note", "credit-note-", "Credit total"
+	}
+	doc := &Document{
+		Title:    title,
+		Filename: filename + invoice.ID,
+		Details: [][2]string{
+			{"Invoice", invoice.Name},
+			{"Date", invoice.InvoiceDate},
+			{"Customer", invoice.PartnerName},
+			{"Salesperson", invoice.Salesperson},
+			{"Payment", invoice.PaymentState},
+		},
+		Columns: []Column{
+			{Header: "Product", Width: 116},
+			{Header: "Quantity", Width: 30, Numeric: true},
+			{Header: "Total", Width: 40, Numeric: true, Money: true},
+		},
+		Rows:   [][]any{},
+		Totals: []Total{{totalLabel, invoice.AmountTotal}},
+	}
+	if invoice.ReversedName != "" {
+		doc.Details = append(doc.Details, [2]string{"Reverses", invoice.ReversedName})
+	}
+	for _, line := range invoice.Lines {
+		doc.Rows = append(doc.Rows, []any{line.Product, line.Quantity, line.PriceTotal})
+	}
+	return doc
+}
+
+// OrderDocument lays out a synced sale order and its lines as an order confirmation
+func OrderDocument(order *Order) *Document {
+	doc := &Document{
+		Title:    "Order Confirmation",
+		Filename: "order-" + order.ID,
+		Details: [][2]string{
+			{"Order", order.Name},
+			{"Date", order.DateOrder},
+			{"Customer", order.PartnerName},
+			{"Expected", order.ExpectedDate},
+			{"Delivery", order.DeliveryStatus},
+		},
+		Columns: []Column{
+			{Header: "Product", Width: 80},
+			{Header: "Quantity", Width: 20, Numeric: true},
+			{Header: "Delivered", Width: 20, Numeric: true},
+			{Header: "Unit price", Width: 26, Numeric: true, Money: true},
+			{Header: "Disc. %", Width: 14, Numeric: true},
+			{Header: "Subtotal", Width: 26, Numeric: true, Money: true},
+		},
+		Rows:   [][]any{},
+		Totals: []Total{{"Order total", order.AmountTotal}},
+	}
+	for _, line := range order.Lines {
+		doc.Rows = append(doc.Rows, []any{
+			line.Product, line.Quantity, line.QtyDelivered, line.PriceUnit, line.Discount, line.Subtotal,
+		})
+	}
+	return doc
+}
@@ -0,0 +1,259 @@
+package documents
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/jung-kurt/gofpdf"
+	"github.com/xuri/excelize/v2"
+)
+
+// Formats a document can be rendered in, with their content types
+var ContentTypes = map[string]string{
+	"pdf":  "application/pdf",
+	"csv":  "text/csv; charset=utf-8",
+	"xlsx": "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+}
+
+// Render renders the document in format (pdf, csv or xlsx)
+func Render(doc *Document, company Company, format string) ([]byte, error) {
+	switch format {
+	case "pdf":
+		return renderPDF(doc, company)
+	case "csv":
+		return renderCSV(doc)
+	case "xlsx":
+		return renderXLSX(doc, company)
+	}
+	return nil, fmt.Errorf("unsupported document format %q", format)
+}
+
+// renderPDF draws an A4 page with the company letterhead, the details block,
+// the table (header repeated on every page) and the totals
+func renderPDF(doc *Document, company Company) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(12, 12, 12)
+	pdf.SetAutoPageBreak(true, 18)
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	tableHeader := func() {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.SetFillColor(34, 60, 100)
+		pdf.SetTextColor(255, 255, 255)
+		for _, col := range doc.Columns {
+			pdf.CellFormat(col.Width, 7, tr(col.Header), "", 0, align(col), true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.SetFont("Helvetica", "", 9)
+	}
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, tr(company.Name), "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+	pdf.AliasNbPages("")
+	pdf.AddPage()
+
+	// Letterhead: logo on the left when configured, company block on the right
+	if company.LogoPath != "" {
+		if _, err := os.Stat(company.LogoPath); err == nil {
+			pdf.ImageOptions(company.LogoPath, 12, 12, 0, 18, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+		}
+	}
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 7, tr(company.Name), "", 1, "R", false, 0, "")
+	pdf.SetFont("Helvetica", "", 9)
+	for _, line := range company.Address {
+		pdf.CellFormat(0, 4.5, tr(line), "", 1, "R", false, 0, "")
+	}
+	if contact := company.contactLine(); contact != "" {
+		pdf.CellFormat(0, 4.5, tr(contact), "", 1, "R", false, 0, "")
+	}
+	pdf.SetY(max(pdf.GetY(), 32) + 4)
+
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 9, tr(doc.Title), "B", 1, "L", false, 0, "")
+	pdf.Ln(3)
+	for _, detail := range doc.Details {
+		pdf.SetFont("Helvetica", "B", 9)
+		pdf.CellFormat(30, 5, tr(detail[0]), "", 0, "L", false, 0, "")
+		pdf.SetFont("Helvetica", "", 9)
+		pdf.CellFormat(0, 5, tr(detail[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	tableHeader()
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	for i, row := range doc.Rows {
+		if pdf.GetY()+6 > pageHeight-bottom {
+			pdf.AddPage()
+			tableHeader()
+		}
+		fill := i%2 == 1
+		pdf.SetFillColor(240, 243, 247)
+		for j, col := range doc.Columns {
+			pdf.CellFormat(col.Width, 6, tr(pdfCell(company, col, row[j])), "", 0, align(col), fill, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.Ln(3)
+	pdf.SetFont("Helvetica", "B", 10)
+	for _, total := range doc.Totals {
+		pdf.CellFormat(146, 6, tr(total.Label), "", 0, "R", false, 0, "")
+		pdf.CellFormat(40, 6, tr(company.Money(total.Amount)), "T", 1, "R", false, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderCSV writes the details, the table and the totals as plain rows
+func renderCSV(doc *Document) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	for _, detail := range doc.Details {
+		w.Write(detail[:])
+	}
+	w.Write(nil)
+
+	headers := make([]string, len(doc.Columns))
+	for i, col := range doc.Columns {
+		headers[i] = col.Header
+	}
+	w.Write(headers)
+	for _, row := range doc.Rows {
+		record := make([]string, len(row))
+		for i, value := range row {
+			if amount, ok := value.(float64); ok {
+				record[i] = strconv.FormatFloat(amount, 'f', 2, 64)
+			} else {
+				record[i] = fmt.Sprintf("%v", value)
+			}
+		}
+		w.Write(record)
+	}
+
+	w.Write(nil)
+	for _, total := range doc.Totals {
+		w.Write([]string{total.Label, strconv.FormatFloat(total.Amount, 'f', 2, 64)})
+	}
+	w.Flush()
+	return buf.Bytes(), w.Error()
+}
+
+// renderXLSX writes one sheet with amounts as numbers, so they can be summed in Excel
+func renderXLSX(doc *Document, company Company) ([]byte, error) {
+	f := excelize.NewFile()
+	defer f.Close()
+	sheet := "Sheet1"
+	if err := f.SetSheetName(sheet, doc.Title); err != nil {
+		return nil, err
+	}
+	sheet = doc.Title
+
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	title, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true, Size: 14}})
+	amount, _ := f.NewStyle(&excelize.Style{NumFmt: 4})
+	header, _ := f.NewStyle(&excelize.Style{
+		Font: &excelize.Font{Bold: true, Color: "FFFFFF"},
+		Fill: excelize.Fill{Type: "pattern", Pattern: 1, Color: []string{"223C64"}},
+	})
+
+	row := 1
+	setRow := func(values ...any) {
+		cell, _ := excelize.CoordinatesToCellName(1, row)
+		f.SetSheetRow(sheet, cell, &values)
+		row++
+	}
+
+	setRow(company.Name)
+	f.SetCellStyle(sheet, "A1", "A1", title)
+	for _, line := range company.Address {
+		setRow(line)
+	}
+	if contact := company.contactLine(); contact != "" {
+		setRow(contact)
+	}
+	row++
+	setRow(doc.Title)
+	f.SetCellStyle(sheet, cellName(1, row-1), cellName(1, row-1), bold)
+	for _, detail := range doc.Details {
+		setRow(detail[0], detail[1])
+	}
+	row++
+
+	headers := make([]any, len(doc.Columns))
+	for i, col := range doc.Columns {
+		headers[i] = col.Header
+		f.SetColWidth(sheet, colName(i+1), colName(i+1), col.Width/2)
+	}
+	setRow(headers...)
+	f.SetCellStyle(sheet, cellName(1, row-1), cellName(len(doc.Columns), row-1), header)
+	for _, values := range doc.Rows {
+		setRow(values...)
+		for i, col := range doc.Columns {
+			if col.Money {
+				f.SetCellStyle(sheet, cellName(i+1, row-1), cellName(i+1, row-1), amount)
+			}
+		}
+	}
+
+	row++
+	last := len(doc.Columns)
+	for _, total := range doc.Totals {
+		f.SetCellValue(sheet, cellName(last-1, row), total.Label)
+		f.SetCellValue(sheet, cellName(last, row), total.Amount)
+		f.SetCellStyle(sheet, cellName(last-1, row), cellName(last-1, row), bold)
+		f.SetCellStyle(sheet, cellName(last, row), cellName(last, row), amount)
+		row++
+	}
+
+	buf, err := f.WriteToBuffer()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func align(col Column) string {
+	if col.Numeric {
+		return "R"
+	}
+	return "L"
+}
+
+// pdfCell formats money columns as money and everything else as it is
+func pdfCell(company Company, col Column, value any) string {
+	switch v := value.(type) {
+	case float64:
+		if col.Money {
+			return company.Money(v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case string:
+		return v
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func cellName(col, row int) string {
+	name, _ := excelize.CoordinatesToCellName(col, row)
+	return name
+}
+
+func colName(col int) string {
+	name, _ := excelize.ColumnNumberToName(col)
+	return name
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// RequireAdmin guards operator endpoints with the X-Admin-Key header.
+// Without ADMIN_API_KEY configured the admin API stays closed.
+func RequireAdmin() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		expected := config.ConfigGlobal.AdminAPIKey
+		provided := c.GetHeader("X-Admin-Key")
+		if expected == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(expected)) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "admin key required"})
+			return
+		}
+		c.Next()
+	}
+}
+
+// ListImportFailureRuns lists the collection/run pairs that had Typesense import failures, newest first
+func ListImportFailureRuns(c *gin.Context) {
+	ctx := c.Request.Context()
+	entries, err := redisutil.RedisClient.ZRevRangeWithScores(ctx, typesenseutil.ImportFailureRunsKey, 0, 99).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs := make([]gin.H, 0, len(entries))
+	for _, entry := range entries {
+		member, _ := entry.Member.(string)
+		collection, runID, _ := strings.Cut(member, ":")
+		count, _ := redisutil.RedisClient.LLen(ctx, typesenseutil.ImportFailuresKey(collection, runID)).Result()
+		runs = append(runs, gin.H{
+			"collection": collection,
+			"run_id":     runID,
+			"failures":   count,
+			"at":         int64(entry.Score),
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetImportFailures returns the rejected documents of one collection and run
+func GetImportFailures(c *gin.Context) {
+	ctx := c.Request.Context()
+	key := typesenseutil.ImportFailuresKey(c.Param("collection"), c.Param("run"))
+	entries, err := redisutil.RedisClient.LRange(ctx, key, 0, -1).Result()
+	if err != nil && err != redis.Nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(entries) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no failures recorded for this run"})
+		return
+	}
+
+	failures := make([]json.RawMessage, 0, len(entries))
+	for _, entry := range entries {
+		failures = append(failures, json.RawMessage(entry))
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"collection": c.Param("collection"),
+		"run_id":     c.Param("run"),
+		"failures":   failures,
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/gin-gonic/gin"
+)
+
+const authSessionTTL = 12 * time.Hour
+
+type loginRequest struct {
+	Login    string `json:"login" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+// Login authenticates the rep against Odoo, keeps their Odoo session in the
+// pool and hands back a bearer token for later requests
+func Login(c *gin.Context) {
+	var body loginRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	uid, err := odoo.SessionPool.Login(body.Login, body.Password)
+	if err != nil {
+		log.Printf("⚠️  Odoo login failed for %s: %v", body.Login, err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid credentials"})
+		return
+	}
+
+	tokenBytes := make([]byte, 32)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	ctx := c.Request.Context()
+	sessionKey := fmt.Sprintf("auth:session:%s", token)
+	if err := redisutil.RedisClient.HSet(ctx, sessionKey, map[string]string{
+		"uid":   strconv.Itoa(uid),
+		"login": body.Login,
+	}).Err(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create session"})
+		return
+	}
+	redisutil.RedisClient.Expire(ctx, sessionKey, authSessionTTL)
+
+	c.JSON(http.StatusOK, gin.H{"token": token, "uid": uid})
+}
+
+// Logout drops the bearer token and the rep's pooled Odoo session
+func Logout(c *gin.Context) {
+	uid := c.GetInt("odoo_uid")
+	redisutil.RedisClient.Del(c.Request.Context(), fmt.Sprintf("auth:session:%s", bearerToken(c)))
+	odoo.SessionPool.Logout(uid)
+	c.Status(http.StatusNoContent)
+}
+
+// RequireRep resolves the bearer token to the rep's Odoo uid and stores it as "odoo_uid"
+func RequireRep() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := bearerToken(c)
+		if token == "" {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "missing bearer token"})
+			return
+		}
+
+		uidStr, err := redisutil.RedisClient.HGet(c.Request.Context(), fmt.Sprintf("auth:session:%s", token), "uid").Result()
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+			return
+		}
+		uid, err := strconv.Atoi(uidStr)
+		if err != nil || uid == 0 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "session expired"})
+			return
+		}
+
+		c.Set("odoo_uid", uid)
+		c.Next()
+	}
+}
+
+// repSession returns the Odoo caller for the authenticated rep, so write-backs
+// are attributed to them. The pool is in memory, so after a backend restart the
+// bearer token outlives the Odoo session: the token is dropped and the request
+// answered 401 so the app logs the rep in again. ok is false once it has answered.
+func repSession(c *gin.Context) (caller odoo.Caller, ok bool) {
+	caller, err := odoo.SessionPool.Session(c.GetInt("odoo_uid"))
+	if err != nil {
+		redisutil.RedisClient.Del(c.Request.Context(), fmt.Sprintf("auth:session:%s", bearerToken(c)))
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "odoo session expired, please log in again"})
+		return nil, false
+	}
+	return caller, true
+}
+
+func bearerToken(c *gin.Context) string {
+	header := c.GetHeader("Authorization")
+	if !strings.HasPrefix(header, "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, "Bearer "))
+}
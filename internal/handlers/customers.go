@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	asynqutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/asynq"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+type customerNoteRequest struct {
+	Body string `json:"body" binding:"required"`
+}
+
+// PostCustomerNote posts a note on the customer's chatter as the authenticated rep
+func PostCustomerNote(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	var body customerNoteRequest
+	if err := c.ShouldBindJSON(&body); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, ok := repSession(c)
+	if !ok {
+		return
+	}
+	result, err := odoo.CallKW(session, "res.partner", "message_post",
+		[]any{[]int{partnerID}},
+		map[string]any{
+			"body":          body.Body,
+			"message_type":  "comment",
+			"subtype_xmlid": "mail.mt_note",
+		},
+	)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	var messageID any
+	json.Unmarshal(result, &messageID)
+	c.JSON(http.StatusCreated, gin.H{"message_id": messageID})
+}
+
+// RefreshCustomer queues a refresh of one customer's record, statement and
+// invoices. Each customer can be refreshed once per CUSTOMER_REFRESH_INTERVAL_SECONDS.
+func RefreshCustomer(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	ctx := c.Request.Context()
+	interval := customerRefreshInterval()
+	limitKey := fmt.Sprintf("customer_refresh:limit:%d", partnerID)
+	allowed, err := redisutil.RedisClient.SetNX(ctx, limitKey, time.Now().Unix(), interval).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if !allowed {
+		wait, _ := redisutil.RedisClient.TTL(ctx, limitKey).Result()
+		seconds := int(math.Ceil(wait.Seconds()))
+		c.Header("Retry-After", strconv.Itoa(seconds))
+		c.JSON(http.StatusTooManyRequests, gin.H{"error": "customer was refreshed recently", "retry_after": seconds})
+		return
+	}
+
+	info, err := asynqutil.AsynqClient.EnqueueContext(ctx, tasks.CustomerRefreshTask(partnerID))
+	if err != nil {
+		// Let the rep try again straight away
+		redisutil.RedisClient.Del(ctx, limitKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue refresh"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"task_id": info.ID})
+}
+
+func customerRefreshInterval() time.Duration {
+	seconds, err := strconv.Atoi(config.ConfigGlobal.CustomerRefreshIntervalSeconds)
+	if err != nil || seconds <= 0 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// pagedDataset serves one page of a generation-based dataset written by the
+// worker (products, customers_page, orders_page). Keys are always resolved
+// through {prefix}:current so a sync in progress is never visible.
+func pagedDataset(prefix string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		page, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+		if err != nil || page < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid page"})
+			return
+		}
+
+		ctx := c.Request.Context()
+		gen, err := redisutil.CurrentGeneration(ctx, prefix)
+		if errors.Is(err, redis.Nil) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "data not synced yet"})
+			return
+		}
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		pageCount, _ := redisutil.RedisClient.Get(ctx, redisutil.GenerationKey(prefix, gen, "page_count")).Int()
+		pageJSON, err := redisutil.RedisClient.Get(ctx, redisutil.GenerationKey(prefix, gen, strconv.Itoa(page))).Bytes()
+		if errors.Is(err, redis.Nil) {
+			pageJSON = []byte("[]")
+		} else if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"page":       page,
+			"page_count": pageCount,
+			"generation": gen,
+			"data":       json.RawMessage(pageJSON),
+		})
+	}
+}
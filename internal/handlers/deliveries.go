@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetDeliveriesDueToday returns the outgoing deliveries scheduled for today on
+// the authenticated rep's orders, as of the last deliveries sync
+func GetDeliveriesDueToday(c *gin.Context) {
+	today := time.Now().Format("2006-01-02")
+	key := fmt.Sprintf("deliveries:due:%d", c.GetInt("odoo_uid"))
+	raw, err := redisutil.RedisClient.Get(c.Request.Context(), key).Bytes()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var due struct {
+		Date       string            `json:"date"`
+		Deliveries []json.RawMessage `json:"deliveries"`
+	}
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &due); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	// A list from yesterday's last sync isn't today's
+	if due.Date != today || due.Deliveries == nil {
+		due.Deliveries = []json.RawMessage{}
+	}
+	c.JSON(http.StatusOK, gin.H{"date": today, "deliveries": due.Deliveries})
+}
@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/documents"
+	"github.com/gin-gonic/gin"
+)
+
+// GetStatementDocument renders the customer's synced 6 month statement as
+// ?format=pdf (default), csv or xlsx
+func GetStatementDocument(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	statement, err := documents.LoadStatement(c.Request.Context(), partnerID)
+	if err != nil {
+		documentError(c, "statement", err)
+		return
+	}
+	sendDocument(c, documents.StatementDocument(statement))
+}
+
+// GetInvoiceDocument renders a synced invoice summary as ?format=pdf (default), csv or xlsx
+func GetInvoiceDocument(c *gin.Context) {
+	invoiceID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || invoiceID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid invoice id"})
+		return
+	}
+
+	invoice, err := documents.LoadInvoice(c.Request.Context(), invoiceID)
+	if err != nil {
+		documentError(c, "invoice", err)
+		return
+	}
+	sendDocument(c, documents.InvoiceDocument(invoice))
+}
+
+func documentError(c *gin.Context, kind string, err error) {
+	if errors.Is(err, documents.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " not synced yet"})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
+func sendDocument(c *gin.Context, doc *documents.Document) {
+	format := c.DefaultQuery("format", "pdf")
+	contentType, ok := documents.ContentTypes[format]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be pdf, csv or xlsx"})
+		return
+	}
+
+	body, err := documents.Render(doc, documents.CompanyFromConfig(), format)
+	if err != nil {
+		log.Printf("❌ Failed to render %s as %s: %v", doc.Filename, format, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to render document"})
+		return
+	}
+
+	// ?inline=1 lets the app preview the PDF instead of downloading it
+	disposition := "attachment"
+	if c.Query("inline") == "1" {
+		disposition = "inline"
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`%s; filename="%s.%s"`, disposition, doc.Filename, format))
+	c.Data(http.StatusOK, contentType, body)
+}
+
+// GetOrderDocument renders a synced order confirmation as ?format=pdf (default), csv or xlsx
+func GetOrderDocument(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || orderID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+
+	order, err := documents.LoadOrder(c.Request.Context(), orderID)
+	if err != nil {
+		documentError(c, "order", err)
+		return
+	}
+	sendDocument(c, documents.OrderDocument(order))
+}
@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"net/http"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// Health reports Redis reachability and which worker currently runs the scheduler
+func Health(c *gin.Context) {
+	ctx := c.Request.Context()
+	if err := redisutil.RedisClient.Ping(ctx).Err(); err != nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "redis unavailable"})
+		return
+	}
+
+	leader, _ := redisutil.LockOwner(ctx, tasks.SchedulerLeaderKey)
+	c.JSON(http.StatusOK, gin.H{
+		"status":           "ok",
+		"scheduler_leader": leader,
+	})
+}
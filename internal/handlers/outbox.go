@@ -0,0 +1,93 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strconv"
+
+	asynqutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/asynq"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/documents"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/notify"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// EmailStatement queues the customer's synced statement as a PDF to the
+// customer's email address
+func EmailStatement(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+	if _, err := documents.LoadStatement(c.Request.Context(), partnerID); err != nil {
+		documentError(c, "statement", err)
+		return
+	}
+	queueEmail(c, &notify.Message{Kind: notify.KindStatement, PartnerID: partnerID})
+}
+
+// EmailOrderConfirmation queues an order confirmation with the order PDF to
+// the ordering customer's email address
+func EmailOrderConfirmation(c *gin.Context) {
+	orderID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || orderID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid order id"})
+		return
+	}
+	order, err := documents.LoadOrder(c.Request.Context(), orderID)
+	if err != nil {
+		documentError(c, "order", err)
+		return
+	}
+	queueEmail(c, &notify.Message{Kind: notify.KindOrderConfirmation, PartnerID: order.PartnerID, OrderID: orderID})
+}
+
+// queueEmail addresses the message to the customer's synced email and puts it in the outbox
+func queueEmail(c *gin.Context, msg *notify.Message) {
+	ctx := c.Request.Context()
+	email, _ := redisutil.RedisClient.HGet(ctx, fmt.Sprintf("customers:%d", msg.PartnerID), "email").Result()
+	if _, err := mail.ParseAddress(email); err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "customer has no valid email address"})
+		return
+	}
+	msg.To = email
+	msg.RequestedBy = strconv.Itoa(c.GetInt("odoo_uid"))
+
+	if err := notify.Queue(ctx, asynqutil.AsynqClient, msg); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message_id": msg.ID, "to": msg.To, "status": msg.Status})
+}
+
+// GetOutboxMessage returns a message's delivery state and log
+func GetOutboxMessage(c *gin.Context) {
+	msg, attempts, err := notify.GetMessage(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, notify.ErrMessageNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "message not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": msg, "attempts": attempts})
+}
+
+// ListOutboxMessages returns the latest messages, newest first (?limit=, default 50)
+func ListOutboxMessages(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	messages, err := notify.RecentMessages(c.Request.Context(), int64(limit))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"messages": messages})
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCustomerPayments returns the customer's synced payments, newest first,
+// each with the invoices it settled (?limit=, default 50)
+func GetCustomerPayments(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit <= 0 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+
+	entries, err := redisutil.RedisClient.LRange(c.Request.Context(), fmt.Sprintf("payments:customer:%d", partnerID), 0, int64(limit-1)).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	payments := make([]json.RawMessage, len(entries))
+	for i, entry := range entries {
+		payments[i] = json.RawMessage(entry)
+	}
+	c.JSON(http.StatusOK, gin.H{"partner_id": partnerID, "payments": payments})
+}
@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// RegisterRoutes wires every backend endpoint onto the router
+func RegisterRoutes(router *gin.Engine) {
+	router.Use(metrics.GinMiddleware())
+	router.GET("/metrics", gin.WrapH(metrics.Handler()))
+
+	router.GET("/health", Health)
+	router.POST("/auth/login", Login)
+	// Signed with ODOO_WEBHOOK_SECRET instead of a rep or admin key
+	router.POST("/webhooks/odoo", OdooWebhook)
+
+	rep := router.Group("/", RequireRep())
+	rep.POST("/auth/logout", Logout)
+	rep.POST("/customers/:id/notes", PostCustomerNote)
+	rep.POST("/customers/:id/refresh", RefreshCustomer)
+	rep.GET("/customers/:id/statement", GetCustomerStatement)
+	rep.GET("/customers/:id/payments", GetCustomerPayments)
+	rep.GET("/customers/:id/statement/document", GetStatementDocument)
+	rep.GET("/invoices/:id/document", GetInvoiceDocument)
+	rep.POST("/customers/:id/statement/email", EmailStatement)
+	rep.POST("/orders/:id/email", EmailOrderConfirmation)
+	rep.GET("/outbox/:id", GetOutboxMessage)
+
+	rep.GET("/products", pagedDataset("products"))
+	rep.GET("/customers", pagedDataset("customers_page"))
+	rep.GET("/orders", pagedDataset("orders_page"))
+	rep.GET("/orders/:id", GetOrder)
+	rep.GET("/orders/:id/document", GetOrderDocument)
+	rep.GET("/deliveries/due-today", GetDeliveriesDueToday)
+	rep.GET("/sync/status", GetSyncStatus)
+
+	admin := router.Group("/admin", RequireAdmin())
+	admin.GET("/import-failures", ListImportFailureRuns)
+	admin.GET("/import-failures/:collection/:run", GetImportFailures)
+	admin.GET("/sync/runs", ListSyncRuns)
+	admin.GET("/sync/runs/:id", GetSyncRun)
+	admin.GET("/outbox", ListOutboxMessages)
+}
@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/statements"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCustomerStatement builds the customer's statement for ?from=&to= (YYYY-MM-DD,
+// inclusive) with current aging. Defaults to the last 6 months up to today.
+func GetCustomerStatement(c *gin.Context) {
+	partnerID, err := strconv.Atoi(c.Param("id"))
+	if err != nil || partnerID <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid customer id"})
+		return
+	}
+
+	today := time.Now()
+	from := c.DefaultQuery("from", today.AddDate(0, -6, 0).Format(statements.DateLayout))
+	to := c.DefaultQuery("to", today.Format(statements.DateLayout))
+	fromDate, fromErr := time.Parse(statements.DateLayout, from)
+	toDate, toErr := time.Parse(statements.DateLayout, to)
+	if fromErr != nil || toErr != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from and to must be YYYY-MM-DD"})
+		return
+	}
+	if fromDate.After(toDate) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "from must not be after to"})
+		return
+	}
+
+	// The service session, like the statement sync, so cached ranges are the same for every rep
+	statement, err := statements.ForRange(c.Request.Context(), odoo.OdooManager, partnerID, from, to)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, statement)
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+// GetSyncStatus returns the latest and last successful run of every synced entity,
+// e.g. "products last synced 12 min ago, 4,812 items"
+func GetSyncStatus(c *gin.Context) {
+	ctx := c.Request.Context()
+	latest, err := redisutil.RedisClient.HGetAll(ctx, tasks.SyncRunsLatestKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	lastOK, err := redisutil.RedisClient.HGetAll(ctx, tasks.SyncRunsLastOKKey).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entities := make(map[string]gin.H, len(latest))
+	for entity, runID := range latest {
+		status := gin.H{}
+		if run, err := loadSyncRun(ctx, runID); err == nil {
+			status["status"] = run.Status
+			status["latest_run"] = run
+		}
+		if run, err := loadSyncRun(ctx, lastOK[entity]); err == nil && run.FinishedAt != nil {
+			ago := time.Since(*run.FinishedAt)
+			status["last_synced_at"] = run.FinishedAt
+			status["last_synced_seconds_ago"] = int64(ago.Seconds())
+			status["items"] = run.Items
+			status["summary"] = fmt.Sprintf("%s last synced %s ago, %d items", entity, ago.Round(time.Minute), run.Items)
+		}
+		entities[entity] = status
+	}
+
+	c.JSON(http.StatusOK, gin.H{"entities": entities})
+}
+
+// ListSyncRuns returns run records newest first, optionally for one entity
+func ListSyncRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit"})
+		return
+	}
+
+	key := tasks.SyncRunsKey
+	if entity := c.Query("entity"); entity != "" {
+		key = tasks.SyncEntityRunsKey(entity)
+	}
+
+	ctx := c.Request.Context()
+	runIDs, err := redisutil.RedisClient.ZRevRange(ctx, key, 0, int64(limit-1)).Result()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	runs := make([]tasks.RunRecord, 0, len(runIDs))
+	for _, runID := range runIDs {
+		// Index entries can outlive the record by up to a retention window
+		if run, err := loadSyncRun(ctx, runID); err == nil {
+			runs = append(runs, run)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"runs": runs})
+}
+
+// GetSyncRun returns one run record
+func GetSyncRun(c *gin.Context) {
+	run, err := loadSyncRun(c.Request.Context(), c.Param("id"))
+	if errors.Is(err, redis.Nil) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "run not found"})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, run)
+}
+
+func loadSyncRun(ctx context.Context, runID string) (tasks.RunRecord, error) {
+	var run tasks.RunRecord
+	if runID == "" {
+		return run, redis.Nil
+	}
+	data, err := redisutil.RedisClient.Get(ctx, tasks.SyncRunKey(runID)).Bytes()
+	if err != nil {
+		return run, err
+	}
+	err = json.Unmarshal(data, &run)
+	return run, err
+}
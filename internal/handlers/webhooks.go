@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	asynqutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/asynq"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/gin-gonic/gin"
+)
+
+// maxWebhookBody bounds the body read before the signature is checked
+const maxWebhookBody = 1 << 20
+
+// webhookMaxAge is how far X-Odoo-Timestamp may be from now, either way.
+// Nonces are remembered for twice that, so a replay inside the window is caught.
+const webhookMaxAge = 5 * time.Minute
+
+type odooWebhookRequest struct {
+	Model string `json:"model"`
+	IDs   []int  `json:"ids"`
+	Event string `json:"event"`
+}
+
+// OdooWebhook receives {model, ids, event} from Odoo automated actions and
+// queues a debounced targeted sync of those records. Odoo sends the unix time
+// in X-Odoo-Timestamp, a unique X-Odoo-Nonce and, in X-Odoo-Signature, the hex
+// HMAC-SHA256 with ODOO_WEBHOOK_SECRET of "{timestamp}.{nonce}.{body}".
+// Stale timestamps and reused nonces are rejected, so a captured call can't be replayed.
+func OdooWebhook(c *gin.Context) {
+	secret := config.ConfigGlobal.OdooWebhookSecret
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "webhook not configured"})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxWebhookBody))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unreadable body"})
+		return
+	}
+	timestamp, nonce := c.GetHeader("X-Odoo-Timestamp"), c.GetHeader("X-Odoo-Nonce")
+	if !validSignature(secret, timestamp, nonce, body, c.GetHeader("X-Odoo-Signature")) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid signature"})
+		return
+	}
+	if !freshTimestamp(timestamp, time.Now()) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "stale or missing timestamp"})
+		return
+	}
+	firstUse, err := redisutil.RedisClient.SetNX(c.Request.Context(), "webhook:nonce:"+nonce, timestamp, 2*webhookMaxAge).Result()
+	if err != nil {
+		log.Printf("❌ Failed to check webhook nonce: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check nonce"})
+		return
+	}
+	if !firstUse {
+		c.JSON(http.StatusConflict, gin.H{"error": "nonce already used"})
+		return
+	}
+
+	var request odooWebhookRequest
+	if err := json.Unmarshal(body, &request); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !tasks.RecordSyncModels[request.Model] {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported model"})
+		return
+	}
+	if len(request.IDs) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ids required"})
+		return
+	}
+
+	if err := tasks.EnqueueRecordSync(c.Request.Context(), asynqutil.AsynqClient, request.Model, request.IDs, webhookDebounce()); err != nil {
+		log.Printf("❌ Failed to queue %s sync from webhook: %v", request.Model, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to queue sync"})
+		return
+	}
+
+	log.Printf("📬 Odoo webhook: %s %s %d records", request.Model, request.Event, len(request.IDs))
+	c.JSON(http.StatusAccepted, gin.H{"queued": len(request.IDs)})
+}
+
+// validSignature checks the hex HMAC-SHA256 of "{timestamp}.{nonce}.{body}",
+// with or without a "sha256=" prefix
+func validSignature(secret, timestamp, nonce string, body []byte, signature string) bool {
+	if timestamp == "" || nonce == "" {
+		return false
+	}
+	provided, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || len(provided) == 0 {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "."))
+	mac.Write(body)
+	return hmac.Equal(provided, mac.Sum(nil))
+}
+
+// freshTimestamp reports whether the unix timestamp is within webhookMaxAge of now
+func freshTimestamp(timestamp string, now time.Time) bool {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+	age := now.Sub(time.Unix(seconds, 0))
+	return age <= webhookMaxAge && age >= -webhookMaxAge
+}
+
+func webhookDebounce() time.Duration {
+	seconds, err := strconv.Atoi(config.ConfigGlobal.WebhookDebounceSeconds)
+	if err != nil || seconds <= 0 {
+		seconds = 10
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func sign(secret, timestamp, nonce, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "." + nonce + "." + body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidSignature(t *testing.T) {
+	const secret, timestamp, nonce = "s3cret", "1780000000", "n-1"
+	body := `{"model":"sale.order","ids":[7],"event":"write"}`
+	good := sign(secret, timestamp, nonce, body)
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	bodyOnly := hex.EncodeToString(mac.Sum(nil))
+
+	tests := []struct {
+		name                        string
+		timestamp, nonce, body, sig string
+		want                        bool
+	}{
+		{"valid", timestamp, nonce, body, good, true},
+		{"valid with sha256= prefix", timestamp, nonce, body, "sha256=" + good, true},
+		{"tampered body", timestamp, nonce, `{"model":"sale.order","ids":[8],"event":"write"}`, good, false},
+		{"replayed with a new timestamp", "1780000300", nonce, body, good, false},
+		{"replayed with a new nonce", timestamp, "n-2", body, good, false},
+		{"wrong secret", timestamp, nonce, body, sign("other", timestamp, nonce, body), false},
+		{"body-only signature of the old scheme", timestamp, nonce, body, bodyOnly, false},
+		{"missing timestamp", "", nonce, body, sign(secret, "", nonce, body), false},
+		{"missing nonce", timestamp, "", body, sign(secret, timestamp, "", body), false},
+		{"not hex", timestamp, nonce, body, "zz", false},
+		{"empty signature", timestamp, nonce, body, "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := validSignature(secret, tt.timestamp, tt.nonce, []byte(tt.body), tt.sig); got != tt.want {
+				t.Errorf("validSignature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFreshTimestamp(t *testing.T) {
+	now := time.Unix(1780000000, 0)
+	at := func(offset time.Duration) string {
+		return strconv.FormatInt(now.Add(offset).Unix(), 10)
+	}
+
+	tests := []struct {
+		name      string
+		timestamp string
+		want      bool
+	}{
+		{"now", at(0), true},
+		{"just inside the window", at(-webhookMaxAge), true},
+		{"too old", at(-webhookMaxAge - time.Second), false},
+		{"small clock skew ahead", at(time.Minute), true},
+		{"too far ahead", at(webhookMaxAge + time.Second), false},
+		{"not a number", "yesterday", false},
+		{"empty", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := freshTimestamp(tt.timestamp, now); got != tt.want {
+				t.Errorf("freshTimestamp(%q) = %v, want %v", tt.timestamp, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"log"
+
+	"github.com/hibiken/asynq"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	queueTasksDesc = prometheus.NewDesc("asynq_queue_tasks",
+		"Tasks in an asynq queue by state.", []string{"queue", "state"}, nil)
+	queueProcessedDesc = prometheus.NewDesc("asynq_queue_processed_total",
+		"Tasks processed (succeeded or failed) per queue.", []string{"queue"}, nil)
+	queueFailedDesc = prometheus.NewDesc("asynq_queue_failed_total",
+		"Task attempts that failed per queue, retries included.", []string{"queue"}, nil)
+	queueLatencyDesc = prometheus.NewDesc("asynq_queue_latency_seconds",
+		"Age of the oldest pending task per queue.", []string{"queue"}, nil)
+)
+
+// queueCollector reads asynq queue stats from Redis on every scrape
+type queueCollector struct {
+	inspector *asynq.Inspector
+}
+
+// RegisterQueueCollector exports depth, retry and failure counts of every asynq queue
+func RegisterQueueCollector(redisOpt asynq.RedisConnOpt) {
+	prometheus.MustRegister(&queueCollector{inspector: asynq.NewInspector(redisOpt)})
+}
+
+func (collector *queueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- queueTasksDesc
+	ch <- queueProcessedDesc
+	ch <- queueFailedDesc
+	ch <- queueLatencyDesc
+}
+
+func (collector *queueCollector) Collect(ch chan<- prometheus.Metric) {
+	queues, err := collector.inspector.Queues()
+	if err != nil {
+		log.Printf("⚠️  Failed to list asynq queues for metrics: %v", err)
+		return
+	}
+
+	for _, queue := range queues {
+		info, err := collector.inspector.GetQueueInfo(queue)
+		if err != nil {
+			log.Printf("⚠️  Failed to read asynq queue %s for metrics: %v", queue, err)
+			continue
+		}
+
+		states := map[string]int{
+			"pending":   info.Pending,
+			"active":    info.Active,
+			"scheduled": info.Scheduled,
+			"retry":     info.Retry,
+			"archived":  info.Archived,
+			"completed": info.Completed,
+		}
+		for state, count := range states {
+			ch <- prometheus.MustNewConstMetric(queueTasksDesc, prometheus.GaugeValue, float64(count), queue, state)
+		}
+		ch <- prometheus.MustNewConstMetric(queueProcessedDesc, prometheus.CounterValue, float64(info.ProcessedTotal), queue)
+		ch <- prometheus.MustNewConstMetric(queueFailedDesc, prometheus.CounterValue, float64(info.FailedTotal), queue)
+		ch <- prometheus.MustNewConstMetric(queueLatencyDesc, prometheus.GaugeValue, info.Latency.Seconds(), queue)
+	}
+}
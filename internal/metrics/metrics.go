@@ -0,0 +1,78 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Both binaries register everything on the default registry and serve it
+// through Handler; collectors a process never touches simply stay at zero.
+
+var (
+	OdooRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "odoo_request_duration_seconds",
+		Help:    "Latency of Odoo JSON-RPC requests.",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	}, []string{"model", "method"})
+
+	OdooRequestErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "odoo_request_errors_total",
+		Help: "Odoo requests that failed at the transport or HTTP level.",
+	}, []string{"model", "method"})
+
+	SyncTaskDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "sync_task_duration_seconds",
+		Help:    "Duration of sync task attempts.",
+		Buckets: []float64{1, 5, 15, 30, 60, 120, 300, 600, 1200, 2400},
+	}, []string{"entity", "status"})
+
+	SyncRecords = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sync_records_total",
+		Help: "Records handled by sync tasks, by outcome (processed, skipped).",
+	}, []string{"entity", "outcome"})
+
+	LastSuccessfulSync = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "last_successful_sync_timestamp",
+		Help: "Unix time the entity last finished a sync successfully.",
+	}, []string{"entity"})
+
+	TypesenseImportDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "typesense_import_duration_seconds",
+		Help:    "Latency of Typesense bulk imports.",
+		Buckets: []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"collection"})
+
+	TypesenseImportDocuments = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "typesense_import_documents_total",
+		Help: "Documents sent to Typesense imports, by result (imported, recovered, failed).",
+	}, []string{"collection", "result"})
+
+	RedisErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "redis_errors_total",
+		Help: "Redis commands that returned an error other than a missing key.",
+	}, []string{"command"})
+
+	SchedulerLeader = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "scheduler_is_leader",
+		Help: "1 while this worker holds the scheduler leader lease.",
+	})
+
+	HTTPRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests served by the API.",
+	}, []string{"method", "route", "status"})
+
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Latency of HTTP requests served by the API.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+// Handler serves the default registry in the Prometheus text format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,125 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/documents"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/hibiken/asynq"
+)
+
+// HandleEmailTask renders an outbox message with its PDF and sends it over SMTP.
+// Failures are retried with backoff (see tasks.RetryDelay); each attempt is
+// appended to the message's delivery log.
+func HandleEmailTask(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.EmailPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid notify:email payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	msg, _, err := GetMessage(ctx, payload.MessageID)
+	if errors.Is(err, ErrMessageNotFound) {
+		log.Printf("⚠️  Outbox message %s expired before delivery", payload.MessageID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if msg.Status == StatusSent {
+		return nil
+	}
+
+	retry, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	msg.Attempts++
+	msg.Status = StatusSending
+	saveMessage(ctx, msg)
+
+	err = deliver(ctx, msg)
+	attempt := Attempt{Attempt: msg.Attempts, At: time.Now().UTC(), Status: StatusSent}
+	if err == nil {
+		sentAt := attempt.At
+		msg.Status, msg.SentAt, msg.LastError = StatusSent, &sentAt, ""
+		logAttempt(ctx, msg, attempt)
+		log.Printf("📧 Sent %s message %s to %s", msg.Kind, msg.ID, msg.To)
+		return nil
+	}
+
+	// Missing data and 5xx rejections won't fix themselves, so they fail straight away
+	final := permanent(err) || errors.Is(err, documents.ErrNotFound) || errors.Is(err, asynq.SkipRetry) || retry >= maxRetry
+	msg.LastError = err.Error()
+	msg.Status = StatusRetry
+	if final {
+		msg.Status = StatusFailed
+	}
+	attempt.Status, attempt.Error = msg.Status, msg.LastError
+	logAttempt(ctx, msg, attempt)
+	log.Printf("❌ Failed to send %s message %s to %s (attempt %d): %v", msg.Kind, msg.ID, msg.To, msg.Attempts, err)
+
+	if final && !errors.Is(err, asynq.SkipRetry) {
+		return fmt.Errorf("%v: %w", err, asynq.SkipRetry)
+	}
+	return err
+}
+
+// deliver renders the message's bodies and attachment from the synced data and sends it
+func deliver(ctx context.Context, msg *Message) error {
+	company := documents.CompanyFromConfig()
+	data := templateData{Company: company}
+	data.CustomerName, _ = redisutil.RedisClient.HGet(ctx, fmt.Sprintf("customers:%d", msg.PartnerID), "display_name").Result()
+	if data.CustomerName == "NA" {
+		data.CustomerName = ""
+	}
+
+	var doc *documents.Document
+	switch msg.Kind {
+	case KindStatement:
+		statement, err := documents.LoadStatement(ctx, msg.PartnerID)
+		if err != nil {
+			return err
+		}
+		data.Statement = statement
+		if data.CustomerName == "" {
+			data.CustomerName = statement.PartnerName
+		}
+		doc = documents.StatementDocument(statement)
+	case KindOrderConfirmation:
+		order, err := documents.LoadOrder(ctx, msg.OrderID)
+		if err != nil {
+			return err
+		}
+		data.Order = order
+		if data.CustomerName == "" {
+			data.CustomerName = order.PartnerName
+		}
+		doc = documents.OrderDocument(order)
+	default:
+		return fmt.Errorf("unknown message kind %q: %w", msg.Kind, asynq.SkipRetry)
+	}
+
+	subject, text, html, err := renderBodies(msg.Kind, data)
+	if err != nil {
+		return err
+	}
+	pdf, err := documents.Render(doc, company, "pdf")
+	if err != nil {
+		return err
+	}
+
+	return send(Email{
+		MessageID: msg.ID,
+		To:        msg.To,
+		Subject:   subject,
+		Text:      text,
+		HTML:      html,
+		Attachments: []Attachment{
+			{Filename: doc.Filename + ".pdf", ContentType: documents.ContentTypes["pdf"], Data: pdf},
+		},
+	})
+}
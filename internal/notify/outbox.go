@@ -0,0 +1,167 @@
+package notify
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Kinds of message the outbox can send
+const (
+	KindStatement         = "statement"
+	KindOrderConfirmation = "order_confirmation"
+)
+
+// Delivery states of a message
+const (
+	StatusQueued  = "queued"
+	StatusSending = "sending"
+	StatusRetry   = "retrying"
+	StatusSent    = "sent"
+	StatusFailed  = "failed"
+)
+
+// messageRetention is how long messages and their delivery logs are kept
+const messageRetention = 30 * 24 * time.Hour
+
+// OutboxIndexKey orders message ids by creation time
+const OutboxIndexKey = "outbox:messages"
+
+// ErrMessageNotFound means the message expired or never existed
+var ErrMessageNotFound = errors.New("outbox message not found")
+
+// Message is one email in the outbox. The body and attachment are rendered at
+// delivery time from the synced data, so a retry sends the latest figures.
+type Message struct {
+	ID          string     `json:"id"`
+	Kind        string     `json:"kind"`
+	PartnerID   int        `json:"partner_id"`
+	OrderID     int        `json:"order_id,omitempty"`
+	To          string     `json:"to"`
+	RequestedBy string     `json:"requested_by,omitempty"`
+	Status      string     `json:"status"`
+	Attempts    int        `json:"attempts"`
+	LastError   string     `json:"last_error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	SentAt      *time.Time `json:"sent_at,omitempty"`
+}
+
+// Attempt is one entry of a message's delivery log
+type Attempt struct {
+	Attempt int       `json:"attempt"`
+	At      time.Time `json:"at"`
+	Status  string    `json:"status"`
+	Error   string    `json:"error,omitempty"`
+}
+
+func messageKey(id string) string {
+	return fmt.Sprintf("outbox:message:%s", id)
+}
+
+func messageLogKey(id string) string {
+	return fmt.Sprintf("outbox:message:%s:log", id)
+}
+
+// Queue saves the message to the outbox and enqueues its delivery
+func Queue(ctx context.Context, client *asynq.Client, msg *Message) error {
+	suffix := make([]byte, 4)
+	rand.Read(suffix)
+	msg.ID = fmt.Sprintf("%d-%s", time.Now().UnixMilli(), hex.EncodeToString(suffix))
+	msg.Status = StatusQueued
+	msg.CreatedAt = time.Now().UTC()
+
+	if err := saveMessage(ctx, msg); err != nil {
+		return err
+	}
+	cutoff := fmt.Sprintf("%d", time.Now().Add(-messageRetention).Unix())
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.ZAdd(ctx, OutboxIndexKey, redis.Z{Score: float64(msg.CreatedAt.Unix()), Member: msg.ID})
+	pipe.ZRemRangeByScore(ctx, OutboxIndexKey, "-inf", cutoff)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if _, err := client.EnqueueContext(ctx, tasks.NotifyEmailTask(msg.ID)); err != nil {
+		msg.Status = StatusFailed
+		msg.LastError = "failed to queue: " + err.Error()
+		saveMessage(ctx, msg)
+		return err
+	}
+	return nil
+}
+
+// GetMessage returns a message and its delivery log, oldest attempt first
+func GetMessage(ctx context.Context, id string) (*Message, []Attempt, error) {
+	raw, err := redisutil.RedisClient.Get(ctx, messageKey(id)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, nil, ErrMessageNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var msg Message
+	if err := json.Unmarshal(raw, &msg); err != nil {
+		return nil, nil, err
+	}
+
+	entries, err := redisutil.RedisClient.LRange(ctx, messageLogKey(id), 0, -1).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	attempts := make([]Attempt, 0, len(entries))
+	for _, entry := range entries {
+		var attempt Attempt
+		if json.Unmarshal([]byte(entry), &attempt) == nil {
+			attempts = append(attempts, attempt)
+		}
+	}
+	return &msg, attempts, nil
+}
+
+// RecentMessages returns up to limit messages, newest first
+func RecentMessages(ctx context.Context, limit int64) ([]Message, error) {
+	ids, err := redisutil.RedisClient.ZRevRange(ctx, OutboxIndexKey, 0, limit-1).Result()
+	if err != nil {
+		return nil, err
+	}
+	messages := make([]Message, 0, len(ids))
+	for _, id := range ids {
+		msg, _, err := GetMessage(ctx, id)
+		if err != nil {
+			continue
+		}
+		messages = append(messages, *msg)
+	}
+	return messages, nil
+}
+
+func saveMessage(ctx context.Context, msg *Message) error {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return redisutil.RedisClient.Set(ctx, messageKey(msg.ID), data, messageRetention).Err()
+}
+
+// logAttempt updates the message and appends the attempt to its delivery log
+func logAttempt(ctx context.Context, msg *Message, attempt Attempt) {
+	entry, _ := json.Marshal(attempt)
+	data, _ := json.Marshal(msg)
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.Set(ctx, messageKey(msg.ID), data, messageRetention)
+	pipe.RPush(ctx, messageLogKey(msg.ID), entry)
+	pipe.Expire(ctx, messageLogKey(msg.ID), messageRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to log delivery of message %s: %v", msg.ID, err)
+	}
+}
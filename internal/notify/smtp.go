@@ -0,0 +1,217 @@
+package notify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+)
+
+// Attachment is a file attached to an email
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Email is a rendered message ready to send
+type Email struct {
+	MessageID   string
+	To          string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// smtpSettings is the SMTP_* configuration with defaults applied
+type smtpSettings struct {
+	host     string
+	port     string
+	username string
+	password string
+	from     *mail.Address
+	// tlsMode is "starttls" (upgrade when offered), "tls" (implicit, port 465) or "none"
+	tlsMode string
+}
+
+func loadSMTPSettings() (smtpSettings, error) {
+	cfg := config.ConfigGlobal
+	settings := smtpSettings{
+		host:     cfg.SMTPHost,
+		port:     cfg.SMTPPort,
+		username: cfg.SMTPUsername,
+		password: cfg.SMTPPassword,
+		tlsMode:  strings.ToLower(cfg.SMTPTLS),
+	}
+	if settings.host == "" {
+		return settings, errors.New("SMTP_HOST is not configured")
+	}
+	if settings.port == "" {
+		settings.port = "587"
+	}
+	if settings.tlsMode == "" {
+		settings.tlsMode = "starttls"
+	}
+	from, err := mail.ParseAddress(cfg.SMTPFrom)
+	if err != nil {
+		return settings, fmt.Errorf("invalid SMTP_FROM %q: %w", cfg.SMTPFrom, err)
+	}
+	settings.from = from
+	return settings, nil
+}
+
+// send delivers the email over SMTP
+func send(email Email) error {
+	settings, err := loadSMTPSettings()
+	if err != nil {
+		return err
+	}
+	to, err := mail.ParseAddress(email.To)
+	if err != nil {
+		return fmt.Errorf("invalid recipient %q: %w", email.To, err)
+	}
+	body, err := buildMIME(settings.from, to, email)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(settings.host, settings.port)
+	tlsConfig := &tls.Config{ServerName: settings.host}
+	var conn net.Conn
+	if settings.tlsMode == "tls" {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: 30 * time.Second}, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", addr, 30*time.Second)
+	}
+	if err != nil {
+		return err
+	}
+	conn.SetDeadline(time.Now().Add(time.Minute))
+
+	client, err := smtp.NewClient(conn, settings.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if settings.tlsMode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return err
+			}
+		}
+	}
+	if settings.username != "" {
+		if err := client.Auth(smtp.PlainAuth("", settings.username, settings.password, settings.host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(settings.from.Address); err != nil {
+		return err
+	}
+	if err := client.Rcpt(to.Address); err != nil {
+		return err
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// permanent reports whether the server rejected the message for good (5xx),
+// in which case retrying won't help
+func permanent(err error) bool {
+	var protoErr *textproto.Error
+	return errors.As(err, &protoErr) && protoErr.Code >= 500
+}
+
+// buildMIME lays the email out as multipart/mixed: a multipart/alternative
+// text and HTML body followed by the attachments
+func buildMIME(from, to *mail.Address, email Email) ([]byte, error) {
+	var buf bytes.Buffer
+	mixed := multipart.NewWriter(&buf)
+
+	domain := "localhost"
+	if at := strings.LastIndex(from.Address, "@"); at >= 0 {
+		domain = from.Address[at+1:]
+	}
+	headers := []string{
+		"From: " + from.String(),
+		"To: " + to.String(),
+		"Subject: " + mime.QEncoding.Encode("utf-8", email.Subject),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		fmt.Sprintf("Message-ID: <%s@%s>", email.MessageID, domain),
+		"MIME-Version: 1.0",
+		fmt.Sprintf("Content-Type: multipart/mixed; boundary=%q", mixed.Boundary()),
+	}
+	buf.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	var alternative bytes.Buffer
+	alt := multipart.NewWriter(&alternative)
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", email.Text},
+		{"text/html; charset=utf-8", email.HTML},
+	} {
+		w, err := alt.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write([]byte(part.body))
+		qp.Close()
+	}
+	alt.Close()
+
+	w, err := mixed.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {fmt.Sprintf("multipart/alternative; boundary=%q", alt.Boundary())},
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.Write(alternative.Bytes())
+
+	for _, attachment := range email.Attachments {
+		w, err := mixed.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {attachment.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": attachment.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(attachment.Data)
+		for len(encoded) > 76 {
+			w.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		w.Write([]byte(encoded + "\r\n"))
+	}
+	if err := mixed.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,118 @@
+package notify
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+)
+
+func TestBuildMIME(t *testing.T) {
+	from := &mail.Address{Name: "Field Sales", Address: "sales@example.com"}
+	to := &mail.Address{Name: "Müller GmbH", Address: "ap@example.org"}
+
+	pdf := bytes.Repeat([]byte("%PDF-1.4 "), 20)
+	tests := []struct {
+		name        string
+		email       Email
+		attachments []string
+	}{
+		{
+			name:  "text and html only",
+			email: Email{MessageID: "m1", Subject: "Order SO123 confirmed", Text: "Thanks", HTML: "<p>Thanks</p>"},
+		},
+		{
+			name: "with attachments",
+			email: Email{MessageID: "m2", Subject: "Statement – März", Text: "Attached", HTML: "<p>Attached</p>",
+				Attachments: []Attachment{
+					{Filename: "statement.pdf", ContentType: "application/pdf", Data: pdf},
+					{Filename: "statement.csv", ContentType: "text/csv", Data: []byte("a,b\n1,2\n")},
+				}},
+			attachments: []string{"statement.pdf", "statement.csv"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := buildMIME(from, to, tt.email)
+			if err != nil {
+				t.Fatal(err)
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(raw))
+			if err != nil {
+				t.Fatalf("not a parseable message: %v", err)
+			}
+
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != tt.email.Subject {
+				t.Errorf("Subject = %q (%v), want %q", subject, err, tt.email.Subject)
+			}
+			if got := msg.Header.Get("Message-ID"); got != "<"+tt.email.MessageID+"@example.com>" {
+				t.Errorf("Message-ID = %q", got)
+			}
+			if msg.Header.Get("MIME-Version") != "1.0" {
+				t.Error("missing MIME-Version")
+			}
+
+			mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+			if err != nil || mediaType != "multipart/mixed" {
+				t.Fatalf("Content-Type = %q (%v), want multipart/mixed", mediaType, err)
+			}
+			parts := multipart.NewReader(msg.Body, params["boundary"])
+
+			// First part: text then HTML alternatives
+			body, err := parts.NextPart()
+			if err != nil {
+				t.Fatal(err)
+			}
+			altType, altParams, _ := mime.ParseMediaType(body.Header.Get("Content-Type"))
+			if altType != "multipart/alternative" {
+				t.Fatalf("first part is %q, want multipart/alternative", altType)
+			}
+			alternatives := multipart.NewReader(body, altParams["boundary"])
+			for _, want := range []struct{ contentType, text string }{
+				{"text/plain", tt.email.Text},
+				{"text/html", tt.email.HTML},
+			} {
+				part, err := alternatives.NextPart()
+				if err != nil {
+					t.Fatalf("missing %s alternative: %v", want.contentType, err)
+				}
+				if !strings.HasPrefix(part.Header.Get("Content-Type"), want.contentType) {
+					t.Errorf("alternative is %q, want %s", part.Header.Get("Content-Type"), want.contentType)
+				}
+				// multipart.Reader decodes quoted-printable parts itself
+				text, _ := io.ReadAll(part)
+				if string(text) != want.text {
+					t.Errorf("%s body = %q, want %q", want.contentType, text, want.text)
+				}
+			}
+
+			// Then one part per attachment, in order
+			for i, filename := range tt.attachments {
+				part, err := parts.NextPart()
+				if err != nil {
+					t.Fatalf("missing attachment %s: %v", filename, err)
+				}
+				if part.FileName() != filename {
+					t.Errorf("attachment %d = %q, want %q", i, part.FileName(), filename)
+				}
+				if part.Header.Get("Content-Transfer-Encoding") != "base64" {
+					t.Errorf("%s is not base64 encoded", filename)
+				}
+				encoded, _ := io.ReadAll(part)
+				for _, line := range strings.Split(strings.TrimSpace(string(encoded)), "\r\n") {
+					if len(line) > 76 {
+						t.Errorf("%s has a %d character line", filename, len(line))
+					}
+				}
+			}
+			if _, err := parts.NextPart(); err != io.EOF {
+				t.Errorf("unexpected extra part: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package notify
+
+import (
+	"bytes"
+	"embed"
+	htmltemplate "html/template"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/documents"
+)
+
+// templates/{kind}.subject.tmpl, {kind}.txt.tmpl and {kind}.html.tmpl per message kind
+//
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// templateData is what the templates of every kind can use
+type templateData struct {
+	Company      documents.Company
+	CustomerName string
+	Statement    *documents.Statement
+	Order        *documents.Order
+}
+
+// renderBodies renders the subject, text and HTML bodies of a message kind
+func renderBodies(kind string, data templateData) (subject, text, html string, err error) {
+	funcs := map[string]any{"money": data.Company.Money}
+
+	render := func(name string, html bool) (string, error) {
+		var buf bytes.Buffer
+		source, err := templateFS.ReadFile("templates/" + name)
+		if err != nil {
+			return "", err
+		}
+		if html {
+			tmpl, err := htmltemplate.New(name).Funcs(funcs).Parse(string(source))
+			if err != nil {
+				return "", err
+			}
+			err = tmpl.Execute(&buf, data)
+			return buf.String(), err
+		}
+		tmpl, err := texttemplate.New(name).Funcs(funcs).Parse(string(source))
+		if err != nil {
+			return "", err
+		}
+		err = tmpl.Execute(&buf, data)
+		return buf.String(), err
+	}
+
+	if subject, err = render(kind+".subject.tmpl", false); err != nil {
+		return
+	}
+	if text, err = render(kind+".txt.tmpl", false); err != nil {
+		return
+	}
+	html, err = render(kind+".html.tmpl", true)
+	return strings.TrimSpace(subject), text, html, err
+}
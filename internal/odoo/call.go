@@ -0,0 +1,53 @@
+package odoo
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// CallKW runs model.method(*args, **kwargs) through web/dataset/call_kw and
+// returns the raw result. Odoo errors in the JSON-RPC envelope are returned as Go errors.
+func CallKW(caller Caller, model, method string, args []any, kwargs map[string]any) (json.RawMessage, error) {
+	if kwargs == nil {
+		kwargs = map[string]any{}
+	}
+	payload := map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "call",
+		"params": map[string]any{
+			"model":  model,
+			"method": method,
+			"args":   args,
+			"kwargs": kwargs,
+		},
+		"id": 2,
+	}
+
+	resp, err := caller.NewRequest("POST", "web/dataset/call_kw", payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Message string `json:"message"`
+			Data    struct {
+				Name    string `json:"name"`
+				Message string `json:"message"`
+			} `json:"data"`
+		} `json:"error"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, err
+	}
+	if rpcResp.Error != nil {
+		if rpcResp.Error.Data.Message != "" {
+			return nil, fmt.Errorf("odoo %s.%s: %s", model, method, rpcResp.Error.Data.Message)
+		}
+		return nil, fmt.Errorf("odoo %s.%s: %s", model, method, rpcResp.Error.Message)
+	}
+	return rpcResp.Result, nil
+}
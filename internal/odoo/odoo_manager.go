@@ -4,22 +4,36 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/cookiejar"
 	"sync"
 	"time"
 
 	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
 )
 
 var OdooManager, _ = NewSessionManger()
 
 const sessionExpiryHouse = 6
 
+// Caller is anything that can send a JSON-RPC request to Odoo.
+// SessionManager talks as the logged in user.
+type Caller interface {
+	NewRequest(method, endpoint string, payload map[string]any) (*http.Response, error)
+}
+
 type SessionManager struct {
 	client    *http.Client
 	lastLogin time.Time
 	lock      sync.Mutex
+
+	// login and password are empty for the service account, which reads
+	// ODOO_USERNAME / ODOO_PASSWORD from config at login time
+	login    string
+	password string
+	uid      int
 }
 
 func NewSessionManger() (*SessionManager, error) {
@@ -35,15 +49,49 @@ func NewSessionManger() (*SessionManager, error) {
 	}, nil
 }
 
+// NewUserSession creates a session that logs in with a rep's own Odoo credentials
+func NewUserSession(login, password string) (*SessionManager, error) {
+	session, err := NewSessionManger()
+	if err != nil {
+		return nil, err
+	}
+	session.login = login
+	session.password = password
+	return session, nil
+}
+
+// UID returns the Odoo user id of the last successful login (0 before login)
+func (session *SessionManager) UID() int {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	return session.uid
+}
+
+// Login forces a fresh authentication and returns the Odoo uid
+func (session *SessionManager) Login() (int, error) {
+	if err := session.odooLogin(); err != nil {
+		return 0, err
+	}
+	return session.UID(), nil
+}
+
+func (session *SessionManager) credentials() (string, string) {
+	if session.login == "" {
+		return config.ConfigGlobal.OdooUsername, config.ConfigGlobal.OdooPassword
+	}
+	return session.login, session.password
+}
+
 func (session *SessionManager) odooLogin() error {
 	session.lock.Lock()
 	defer session.lock.Unlock()
+	login, password := session.credentials()
 	payload := map[string]any{
 		"jsonrpc": "2.0",
 		"params": map[string]any{
 			"db":       config.ConfigGlobal.OdooDB,
-			"login":    config.ConfigGlobal.OdooUsername,
-			"password": config.ConfigGlobal.OdooPassword,
+			"login":    login,
+			"password": password,
 		},
 	}
 	loginUrl := config.ConfigGlobal.OdooURL + "web/session/authenticate"
@@ -70,6 +118,7 @@ func (session *SessionManager) odooLogin() error {
 	if result.Result.UID == 0 {
 		return errors.New("Odoo Login Failed")
 	}
+	session.uid = result.Result.UID
 	session.lastLogin = time.Now().UTC()
 	return nil
 }
@@ -86,6 +135,55 @@ func (session *SessionManager) ensureSession() error {
 }
 
 func (session *SessionManager) NewRequest(method, endpoint string, payload map[string]any) (*http.Response, error) {
+	model, rpcMethod := requestLabels(payload)
+	start := time.Now()
+	response, err := session.send(method, endpoint, payload)
+	metrics.OdooRequestDuration.WithLabelValues(model, rpcMethod).Observe(time.Since(start).Seconds())
+	if err != nil || response.StatusCode >= http.StatusBadRequest {
+		metrics.OdooRequestErrors.WithLabelValues(model, rpcMethod).Inc()
+		return response, err
+	}
+	if rpcError(response) {
+		metrics.OdooRequestErrors.WithLabelValues(model, rpcMethod).Inc()
+	}
+	return response, nil
+}
+
+// rpcError reports whether the response carries a JSON-RPC error member. Odoo
+// answers access errors, bad fields and server exceptions with HTTP 200, so the
+// body is read here once and handed back to the caller unchanged.
+func rpcError(response *http.Response) bool {
+	body, err := io.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = io.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return true
+	}
+
+	var envelope struct {
+		Error json.RawMessage `json:"error"`
+	}
+	if json.Unmarshal(body, &envelope) != nil {
+		return false
+	}
+	return len(envelope.Error) > 0 && string(envelope.Error) != "null"
+}
+
+// requestLabels pulls the model and method out of a call_kw payload for metrics
+func requestLabels(payload map[string]any) (string, string) {
+	params, _ := payload["params"].(map[string]any)
+	model, _ := params["model"].(string)
+	method, _ := params["method"].(string)
+	if model == "" {
+		model = "none"
+	}
+	if method == "" {
+		method = "none"
+	}
+	return model, method
+}
+
+func (session *SessionManager) send(method, endpoint string, payload map[string]any) (*http.Response, error) {
 	if err := session.ensureSession(); err != nil {
 		return nil, err
 	}
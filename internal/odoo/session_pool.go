@@ -0,0 +1,75 @@
+package odoo
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// SessionPool holds one Odoo session per rep, keyed by Odoo uid.
+// Background sync keeps using OdooManager (the service account); write-backs
+// from the backend go through the pool so Odoo records the real author and
+// applies the rep's own access rules.
+var SessionPool = NewSessionPool()
+
+// ErrNoSession means the rep has no live Odoo session in the pool (the backend
+// restarted or the session idled out) and has to log in again
+var ErrNoSession = errors.New("no live odoo session for this rep")
+
+const sessionIdleExpiryHours = 12
+
+type pooledSession struct {
+	session  *SessionManager
+	lastUsed time.Time
+}
+
+type Pool struct {
+	sessions map[int]*pooledSession
+	lock     sync.Mutex
+}
+
+func NewSessionPool() *Pool {
+	return &Pool{sessions: make(map[int]*pooledSession)}
+}
+
+// Login authenticates a rep with their own credentials and keeps the session
+// in the pool. Returns the rep's Odoo uid.
+func (pool *Pool) Login(login, password string) (int, error) {
+	session, err := NewUserSession(login, password)
+	if err != nil {
+		return 0, err
+	}
+	uid, err := session.Login()
+	if err != nil {
+		return 0, err
+	}
+
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	pool.sessions[uid] = &pooledSession{session: session, lastUsed: time.Now()}
+	return uid, nil
+}
+
+// Session returns the rep's own Odoo session. There is deliberately no
+// fallback to the service account: a rep without a live session gets
+// ErrNoSession, so writes are never made under the integration user.
+func (pool *Pool) Session(uid int) (Caller, error) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+
+	if pooled, ok := pool.sessions[uid]; ok {
+		if time.Since(pooled.lastUsed) < sessionIdleExpiryHours*time.Hour {
+			pooled.lastUsed = time.Now()
+			return pooled.session, nil
+		}
+		delete(pool.sessions, uid)
+	}
+	return nil, ErrNoSession
+}
+
+// Logout drops the rep's session from the pool
+func (pool *Pool) Logout(uid int) {
+	pool.lock.Lock()
+	defer pool.lock.Unlock()
+	delete(pool.sessions, uid)
+}
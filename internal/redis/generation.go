@@ -0,0 +1,68 @@
+package redisutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Generation-based datasets: each full sync writes into {prefix}:g{N}:... and
+// then flips {prefix}:current to N, so readers never see a half-written sync.
+
+// GenerationKey returns the key of suffix inside generation gen, e.g. products:g7:3
+func GenerationKey(prefix string, gen int64, suffix string) string {
+	return fmt.Sprintf("%s:g%d:%s", prefix, gen, suffix)
+}
+
+// GenerationPointerKey holds the number of the generation readers should use
+func GenerationPointerKey(prefix string) string {
+	return prefix + ":current"
+}
+
+// CurrentGeneration returns the live generation of a dataset, or redis.Nil if none was committed yet
+func CurrentGeneration(ctx context.Context, prefix string) (int64, error) {
+	return RedisClient.Get(ctx, GenerationPointerKey(prefix)).Int64()
+}
+
+// CurrentKey resolves suffix through the dataset's current pointer
+func CurrentKey(ctx context.Context, prefix, suffix string) (string, error) {
+	gen, err := CurrentGeneration(ctx, prefix)
+	if err != nil {
+		if err == redis.Nil {
+			return "", fmt.Errorf("%s has no committed generation yet", prefix)
+		}
+		return "", err
+	}
+	return GenerationKey(prefix, gen, suffix), nil
+}
+
+// FindRecord looks a record up by id in the current generation of a paged
+// dataset, through its id -> page index. It returns redis.Nil if the dataset
+// has no generation yet or the record isn't in it.
+func FindRecord(ctx context.Context, prefix, id string) (map[string]any, error) {
+	gen, err := CurrentGeneration(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	page, err := RedisClient.HGet(ctx, GenerationKey(prefix, gen, "index"), id).Result()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := RedisClient.Get(ctx, GenerationKey(prefix, gen, page)).Bytes()
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", GenerationKey(prefix, gen, page), err)
+	}
+	for _, record := range records {
+		if fmt.Sprintf("%v", record["id"]) == id {
+			return record, nil
+		}
+	}
+	return nil, redis.Nil
+}
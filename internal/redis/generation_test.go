@@ -0,0 +1,109 @@
+package redisutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+func TestGenerationKeys(t *testing.T) {
+	tests := []struct {
+		prefix string
+		gen    int64
+		suffix string
+		want   string
+	}{
+		{"products", 7, "3", "products:g7:3"},
+		{"customers_page", 12, "index", "customers_page:g12:index"},
+		{"orders_page", 1, "page_count", "orders_page:g1:page_count"},
+	}
+	for _, tt := range tests {
+		if got := GenerationKey(tt.prefix, tt.gen, tt.suffix); got != tt.want {
+			t.Errorf("GenerationKey(%q, %d, %q) = %q, want %q", tt.prefix, tt.gen, tt.suffix, got, tt.want)
+		}
+	}
+
+	if got := GenerationPointerKey("products"); got != "products:current" {
+		t.Errorf("GenerationPointerKey = %q", got)
+	}
+}
+
+// testRedis connects to TEST_REDIS_URL, or skips: there is no Redis in unit test runs by default
+func testRedis(t *testing.T) string {
+	t.Helper()
+	url := os.Getenv("TEST_REDIS_URL")
+	if url == "" {
+		t.Skip("TEST_REDIS_URL not set")
+	}
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := RedisClient
+	RedisClient = redis.NewClient(opts)
+	prefix := fmt.Sprintf("test_generation_%d", time.Now().UnixNano())
+	t.Cleanup(func() {
+		ctx := context.Background()
+		if keys, _ := RedisClient.Keys(ctx, prefix+":*").Result(); len(keys) > 0 {
+			RedisClient.Del(ctx, keys...)
+		}
+		RedisClient.Close()
+		RedisClient = previous
+	})
+	return prefix
+}
+
+func TestFindRecord(t *testing.T) {
+	prefix := testRedis(t)
+	ctx := context.Background()
+
+	if _, err := FindRecord(ctx, prefix, "1"); !errors.Is(err, redis.Nil) {
+		t.Fatalf("before any generation: err = %v, want redis.Nil", err)
+	}
+
+	// Generation 1 is retired, generation 2 is current
+	write := func(gen int64, page string, records ...map[string]any) {
+		raw, _ := json.Marshal(records)
+		RedisClient.Set(ctx, GenerationKey(prefix, gen, page), raw, 0)
+		for _, record := range records {
+			RedisClient.HSet(ctx, GenerationKey(prefix, gen, "index"), fmt.Sprint(record["id"]), page)
+		}
+	}
+	write(1, "1", map[string]any{"id": 1, "name": "old"})
+	write(2, "1", map[string]any{"id": 1, "name": "new"}, map[string]any{"id": 2, "name": "second"})
+	write(2, "2", map[string]any{"id": 3, "name": "third"})
+	RedisClient.Set(ctx, GenerationPointerKey(prefix), 2, 0)
+
+	tests := []struct {
+		id       string
+		wantName string
+		wantErr  error
+	}{
+		{"1", "new", nil},
+		{"2", "second", nil},
+		{"3", "third", nil},
+		{"4", "", redis.Nil},
+	}
+	for _, tt := range tests {
+		t.Run("id "+tt.id, func(t *testing.T) {
+			record, err := FindRecord(ctx, prefix, tt.id)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && record["name"] != tt.wantName {
+				t.Errorf("name = %v, want %s", record["name"], tt.wantName)
+			}
+		})
+	}
+
+	key, err := CurrentKey(ctx, prefix, "index")
+	if err != nil || key != GenerationKey(prefix, 2, "index") {
+		t.Errorf("CurrentKey = %q (%v)", key, err)
+	}
+}
@@ -0,0 +1,95 @@
+package redisutil
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+)
+
+// Elector campaigns for a leadership lease. The winner runs lead() with a
+// context that is cancelled as soon as the lease can't be renewed, after which
+// every instance (including the old leader) campaigns again.
+type Elector struct {
+	key     string
+	id      string
+	ttl     time.Duration
+	leading atomic.Bool
+}
+
+func NewElector(key, id string, ttl time.Duration) *Elector {
+	return &Elector{key: key, id: id, ttl: ttl}
+}
+
+func (elector *Elector) ID() string { return elector.id }
+
+// IsLeader reports whether this instance currently holds the lease
+func (elector *Elector) IsLeader() bool {
+	return elector.leading.Load()
+}
+
+// Run campaigns until ctx is done. lead must return once its context is cancelled;
+// returning earlier (e.g. because it failed to start) gives up leadership.
+func (elector *Elector) Run(ctx context.Context, lead func(ctx context.Context)) {
+	interval := elector.ttl / 3
+	for {
+		wait := interval
+		lock, err := AcquireLockAs(ctx, elector.key, elector.id, elector.ttl)
+		switch {
+		case err == nil:
+			if stepped := elector.hold(ctx, lock, lead); stepped {
+				// Back off a full lease so another instance gets to try
+				wait = elector.ttl
+			}
+		case !errors.Is(err, ErrLockHeld):
+			log.Printf("⚠️  Leader election on %s failed: %v", elector.key, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(wait):
+		}
+	}
+}
+
+// hold runs lead while renewing the lease, and steps down when renewal fails or
+// lead returns on its own. It reports whether lead gave up by itself.
+func (elector *Elector) hold(ctx context.Context, lock *Lock, lead func(ctx context.Context)) bool {
+	leaderCtx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	elector.leading.Store(true)
+	log.Printf("👑 %s is now leader of %s (term %d)", elector.id, elector.key, lock.Token())
+	go func() {
+		defer close(done)
+		lead(leaderCtx)
+	}()
+
+	ticker := time.NewTicker(elector.ttl / 3)
+	defer ticker.Stop()
+	stepped := false
+	for renewing := true; renewing; {
+		select {
+		case <-ticker.C:
+			if err := lock.Renew(ctx); err != nil {
+				log.Printf("⚠️  %s lost leadership of %s: %v", elector.id, elector.key, err)
+				renewing = false
+			}
+		case <-done:
+			log.Printf("⚠️  %s stopped leading %s, stepping down", elector.id, elector.key)
+			stepped = true
+			renewing = false
+		case <-ctx.Done():
+			renewing = false
+		}
+	}
+
+	elector.leading.Store(false)
+	cancel()
+	<-done
+	// Hand over right away instead of waiting for the lease to expire
+	lock.Release(context.WithoutCancel(ctx))
+	return stepped
+}
@@ -0,0 +1,217 @@
+package redisutil
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrLockHeld is returned when someone else owns the lock
+var ErrLockHeld = errors.New("lock is held by another owner")
+
+// ErrStaleFence is returned when a newer lock holder exists, so the caller's writes must not commit
+var ErrStaleFence = errors.New("fencing token is stale, a newer run holds the lock")
+
+// Lock is a lease on a Redis key. Only the owner token can renew or release it,
+// and every acquisition gets a fencing token from {key}:fence that grows
+// monotonically, so writers can tell whether they still belong to the newest holder.
+type Lock struct {
+	key   string
+	owner string
+	token int64
+	ttl   time.Duration
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+var renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+
+// AcquireLock takes the lock for ttl under a random owner token, or returns ErrLockHeld
+func AcquireLock(ctx context.Context, key string, ttl time.Duration) (*Lock, error) {
+	ownerBytes := make([]byte, 16)
+	if _, err := rand.Read(ownerBytes); err != nil {
+		return nil, err
+	}
+	return AcquireLockAs(ctx, key, hex.EncodeToString(ownerBytes), ttl)
+}
+
+// AcquireLockAs takes the lock with a caller-chosen owner, which others can read
+// back with LockOwner. The owner must be unique among contenders.
+func AcquireLockAs(ctx context.Context, key, owner string, ttl time.Duration) (*Lock, error) {
+	acquired, err := RedisClient.SetNX(ctx, key, owner, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !acquired {
+		return nil, ErrLockHeld
+	}
+
+	token, err := RedisClient.Incr(ctx, fenceKey(key)).Result()
+	if err != nil {
+		releaseScript.Run(ctx, RedisClient, []string{key}, owner)
+		return nil, err
+	}
+	return &Lock{key: key, owner: owner, token: token, ttl: ttl, stop: make(chan struct{})}, nil
+}
+
+// ResumeLock rebuilds a handle to a lock acquired elsewhere (e.g. by another
+// task of the same workflow) from its owner and fencing token
+func ResumeLock(key, owner string, token int64, ttl time.Duration) *Lock {
+	return &Lock{key: key, owner: owner, token: token, ttl: ttl, stop: make(chan struct{})}
+}
+
+func (lock *Lock) Owner() string { return lock.owner }
+func (lock *Lock) Token() int64  { return lock.token }
+
+// Renew extends the lease, failing if the lock expired or changed hands
+func (lock *Lock) Renew(ctx context.Context) error {
+	renewed, err := renewScript.Run(ctx, RedisClient, []string{lock.key}, lock.owner, lock.ttl.Milliseconds()).Int()
+	if err != nil {
+		return err
+	}
+	if renewed == 0 {
+		return fmt.Errorf("lost lock %s", lock.key)
+	}
+	return nil
+}
+
+// KeepAlive renews the lease every ttl/3 until Stop or Release is called,
+// or ctx is done. It gives up once the lease has been lost.
+func (lock *Lock) KeepAlive(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(lock.ttl / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := lock.Renew(context.WithoutCancel(ctx)); err != nil {
+					log.Printf("⚠️  Lease renewal of %s failed: %v", lock.key, err)
+					return
+				}
+			case <-lock.stop:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+// Stop ends background renewal without releasing the lock
+func (lock *Lock) Stop() {
+	lock.stopOnce.Do(func() { close(lock.stop) })
+}
+
+// Release stops renewal and deletes the lock, but only while we still own it
+func (lock *Lock) Release(ctx context.Context) error {
+	lock.Stop()
+	_, err := releaseScript.Run(ctx, RedisClient, []string{lock.key}, lock.owner).Result()
+	return err
+}
+
+// LockOwner returns the current owner of a lock, or "" when nobody holds it
+func LockOwner(ctx context.Context, key string) (string, error) {
+	owner, err := RedisClient.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return "", nil
+	}
+	return owner, err
+}
+
+func fenceKey(key string) string {
+	return key + ":fence"
+}
+
+type fenceContextKey struct{}
+
+type fence struct {
+	key   string
+	token int64
+}
+
+// WithFence attaches a fencing token to ctx. Writes through FencedTx and
+// CheckFence with this context fail once a newer holder took the lock.
+func WithFence(ctx context.Context, lockKey string, token int64) context.Context {
+	return context.WithValue(ctx, fenceContextKey{}, fence{key: fenceKey(lockKey), token: token})
+}
+
+// CheckFence returns ErrStaleFence if ctx carries a fencing token that is no longer current
+func CheckFence(ctx context.Context) error {
+	f, ok := ctx.Value(fenceContextKey{}).(fence)
+	if !ok {
+		return nil
+	}
+	current, err := RedisClient.Get(ctx, f.key).Int64()
+	if err != nil {
+		return err
+	}
+	if current != f.token {
+		return ErrStaleFence
+	}
+	return nil
+}
+
+// FencedWatch runs fn with keys WATCHed, plus the fencing token in ctx when
+// there is one; fn commits through tx.TxPipelined. A fence that moved returns
+// ErrStaleFence, a watched key changed by someone else returns redis.TxFailedErr.
+func FencedWatch(ctx context.Context, fn func(tx *redis.Tx) error, keys ...string) error {
+	f, ok := ctx.Value(fenceContextKey{}).(fence)
+	if !ok {
+		return RedisClient.Watch(ctx, fn, keys...)
+	}
+
+	return RedisClient.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, f.key).Int64()
+		if err != nil {
+			return err
+		}
+		if current != f.token {
+			return ErrStaleFence
+		}
+		return fn(tx)
+	}, append(keys, f.key)...)
+}
+
+// FencedTx runs fn in a MULTI/EXEC transaction that only commits while the
+// fencing token in ctx is current. Without a token it is a plain transaction.
+func FencedTx(ctx context.Context, fn func(pipe redis.Pipeliner) error) error {
+	f, ok := ctx.Value(fenceContextKey{}).(fence)
+	if !ok {
+		_, err := RedisClient.TxPipelined(ctx, fn)
+		return err
+	}
+
+	err := RedisClient.Watch(ctx, func(tx *redis.Tx) error {
+		current, err := tx.Get(ctx, f.key).Int64()
+		if err != nil {
+			return err
+		}
+		if current != f.token {
+			return ErrStaleFence
+		}
+		_, err = tx.TxPipelined(ctx, fn)
+		return err
+	}, f.key)
+	if errors.Is(err, redis.TxFailedErr) {
+		return ErrStaleFence
+	}
+	return err
+}
@@ -0,0 +1,47 @@
+package redisutil
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
+	"github.com/redis/go-redis/v9"
+)
+
+// metricsHook counts failed Redis commands; a missing key (redis.Nil) is not a failure
+type metricsHook struct{}
+
+func (metricsHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		conn, err := next(ctx, network, addr)
+		if err != nil {
+			metrics.RedisErrors.WithLabelValues("dial").Inc()
+		}
+		return conn, err
+	}
+}
+
+func (metricsHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		err := next(ctx, cmd)
+		countErrors(cmd)
+		return err
+	}
+}
+
+func (metricsHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		err := next(ctx, cmds)
+		countErrors(cmds...)
+		return err
+	}
+}
+
+func countErrors(cmds ...redis.Cmder) {
+	for _, cmd := range cmds {
+		if err := cmd.Err(); err != nil && !errors.Is(err, redis.Nil) {
+			metrics.RedisErrors.WithLabelValues(cmd.Name()).Inc()
+		}
+	}
+}
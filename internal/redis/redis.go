@@ -29,6 +29,8 @@ func ConnectToRedis(config *config.Config) {
 		Addr: addr,
 		DB:   db,
 	})
+	RedisClient.AddHook(writeCounterHook{})
+	RedisClient.AddHook(metricsHook{})
 	_, err = RedisClient.Ping(context.Background()).Result()
 	if err != nil {
 		log.Fatalf("Error while connecting redis: %v", err)
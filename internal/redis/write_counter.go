@@ -0,0 +1,62 @@
+package redisutil
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// writeCommands are the commands counted as key writes
+var writeCommands = map[string]bool{
+	"set": true, "setnx": true, "setex": true, "mset": true, "incr": true, "decr": true,
+	"hset": true, "hmset": true, "hdel": true,
+	"sadd": true, "srem": true,
+	"zadd": true, "zrem": true, "zremrangebyscore": true,
+	"lpush": true, "rpush": true, "ltrim": true,
+	"del": true, "unlink": true,
+}
+
+type writeCounterKey struct{}
+
+// WithWriteCounter makes every Redis write issued with the returned context
+// increment counter, so a task can report how many keys it wrote
+func WithWriteCounter(ctx context.Context, counter *atomic.Int64) context.Context {
+	return context.WithValue(ctx, writeCounterKey{}, counter)
+}
+
+// writeCounterHook counts write commands against the counter carried in the context
+type writeCounterHook struct{}
+
+func (writeCounterHook) DialHook(next redis.DialHook) redis.DialHook {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return next(ctx, network, addr)
+	}
+}
+
+func (writeCounterHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		countWrites(ctx, cmd)
+		return next(ctx, cmd)
+	}
+}
+
+func (writeCounterHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		countWrites(ctx, cmds...)
+		return next(ctx, cmds)
+	}
+}
+
+func countWrites(ctx context.Context, cmds ...redis.Cmder) {
+	counter, ok := ctx.Value(writeCounterKey{}).(*atomic.Int64)
+	if !ok {
+		return
+	}
+	for _, cmd := range cmds {
+		if writeCommands[cmd.Name()] {
+			counter.Add(1)
+		}
+	}
+}
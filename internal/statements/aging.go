@@ -0,0 +1,72 @@
+package statements
+
+import (
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+)
+
+// agingBuckets are the days-past-due bands, in report order
+var agingBuckets = []struct {
+	name    string
+	maxDays int
+}{
+	{"current", 0},
+	{"1_30", 30},
+	{"31_60", 60},
+	{"61_90", 90},
+	{"90_plus", -1},
+}
+
+// Aging splits the open invoices and credit notes of the partner's commercial
+// entity (so invoices addressed to its contacts count) by days past
+// invoice_date_due. Odoo only knows today's residuals, so aging is always
+// current, whatever range the statement covers; as_of says so.
+func Aging(caller odoo.Caller, partnerID int) (map[string]any, error) {
+	asOf := time.Now().Format(DateLayout)
+	asOfDate, _ := time.Parse(DateLayout, asOf)
+
+	moves, err := searchRead(caller, "account.move", []any{
+		[]any{"commercial_partner_id", "=", partnerID},
+		[]any{"move_type", "in", []string{"out_invoice", "out_refund"}},
+		[]any{"state", "=", "posted"},
+		[]any{"amount_residual", "!=", 0},
+	}, []string{"invoice_date", "invoice_date_due", "amount_residual_signed"}, "", 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	aging := bucketAging(moves, asOfDate)
+	aging["as_of"] = asOf
+	return aging, nil
+}
+
+// bucketAging sums the residuals of moves into agingBuckets by days past due at asOf
+func bucketAging(moves []map[string]any, asOfDate time.Time) map[string]any {
+	totals := make(map[string]float64, len(agingBuckets))
+	total := 0.0
+	for _, move := range moves {
+		due, err := time.Parse(DateLayout, getString(move["invoice_date_due"]))
+		if err != nil {
+			// No due date: due on the invoice date
+			due, _ = time.Parse(DateLayout, getString(move["invoice_date"]))
+		}
+		daysPastDue := int(asOfDate.Sub(due).Hours() / 24)
+		amount := getFloat(move["amount_residual_signed"])
+
+		for _, bucket := range agingBuckets {
+			if bucket.maxDays < 0 || daysPastDue <= bucket.maxDays {
+				totals[bucket.name] += amount
+				break
+			}
+		}
+		total += amount
+	}
+
+	aging := make(map[string]any, len(agingBuckets)+1)
+	for _, bucket := range agingBuckets {
+		aging[bucket.name] = roundFloat(totals[bucket.name], 2)
+	}
+	aging["total"] = roundFloat(total, 2)
+	return aging
+}
@@ -0,0 +1,64 @@
+package statements
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBucketAging(t *testing.T) {
+	asOf, _ := time.Parse(DateLayout, "2026-06-30")
+
+	tests := []struct {
+		name   string
+		due    any
+		date   string
+		bucket string
+	}{
+		{"not yet due", "2026-07-15", "2026-06-15", "current"},
+		{"due today", "2026-06-30", "2026-06-01", "current"},
+		{"1 day past due", "2026-06-29", "2026-06-01", "1_30"},
+		{"30 days past due", "2026-05-31", "2026-05-01", "1_30"},
+		{"31 days past due", "2026-05-30", "2026-05-01", "31_60"},
+		{"60 days past due", "2026-05-01", "2026-04-01", "31_60"},
+		{"61 days past due", "2026-04-30", "2026-04-01", "61_90"},
+		{"90 days past due", "2026-04-01", "2026-03-01", "61_90"},
+		{"91 days past due", "2026-03-31", "2026-03-01", "90_plus"},
+		{"no due date falls back to invoice date", false, "2026-05-15", "31_60"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			aging := bucketAging([]map[string]any{
+				{"invoice_date": tt.date, "invoice_date_due": tt.due, "amount_residual_signed": 100.0},
+			}, asOf)
+
+			for _, bucket := range agingBuckets {
+				want := 0.0
+				if bucket.name == tt.bucket {
+					want = 100
+				}
+				if got := aging[bucket.name]; got != want {
+					t.Errorf("%s = %v, want %v", bucket.name, got, want)
+				}
+			}
+			if got := aging["total"]; got != 100.0 {
+				t.Errorf("total = %v, want 100", got)
+			}
+		})
+	}
+}
+
+func TestBucketAgingNetsCreditNotes(t *testing.T) {
+	asOf, _ := time.Parse(DateLayout, "2026-06-30")
+	aging := bucketAging([]map[string]any{
+		{"invoice_date": "2026-06-01", "invoice_date_due": "2026-06-10", "amount_residual_signed": 250.0},
+		{"invoice_date": "2026-06-05", "invoice_date_due": "2026-06-05", "amount_residual_signed": -50.0},
+	}, asOf)
+
+	if got := aging["1_30"]; got != 200.0 {
+		t.Errorf("1_30 = %v, want 200", got)
+	}
+	if got := aging["total"]; got != 200.0 {
+		t.Errorf("total = %v, want 200", got)
+	}
+}
@@ -0,0 +1,122 @@
+package statements
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+)
+
+const (
+	// openRangeTTL caches ranges that include today, whose figures still move
+	openRangeTTL = 10 * time.Minute
+	// closedRangeTTL caches ranges wholly in the past
+	closedRangeTTL = 24 * time.Hour
+)
+
+// DashboardKey is where the statement sync keeps a partner's 6 month statement
+func DashboardKey(partnerID int) string {
+	return fmt.Sprintf("dashboard:customer_statement:%d", partnerID)
+}
+
+// CacheKey is where the statement of a partner and range is cached
+func CacheKey(partnerID int, from, to string) string {
+	return fmt.Sprintf("statement:%d:%s:%s", partnerID, from, to)
+}
+
+// ForRange builds a partner's statement for from..to (inclusive dates): the
+// opening balance before from, a row per ledger line with the running balance,
+// and current aging. Ledger results are cached per partner and range; aging is
+// read fresh, since it moves with every payment whatever the range.
+func ForRange(ctx context.Context, caller odoo.Caller, partnerID int, from, to string) (map[string]any, error) {
+	key := CacheKey(partnerID, from, to)
+	if cached, err := redisutil.RedisClient.Get(ctx, key).Bytes(); err == nil {
+		var statement map[string]any
+		if json.Unmarshal(cached, &statement) == nil {
+			statement["cached"] = true
+			return withAging(caller, partnerID, statement)
+		}
+	}
+
+	openings, err := OpeningBalances(caller, []int{partnerID}, from)
+	if err != nil {
+		return nil, err
+	}
+	opening := openings[partnerID]
+
+	var statement map[string]any
+	err = StreamLines(caller, []int{partnerID}, from, to, func(_ int, lines []map[string]any, moves map[int]map[string]any) error {
+		statement = Build(partnerID, opening, lines, moves)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// No activity in the range still has an opening (and equal closing) balance
+	if statement == nil {
+		name, err := partnerName(caller, partnerID)
+		if err != nil {
+			return nil, err
+		}
+		statement = map[string]any{
+			"partner_id":      partnerID,
+			"partner_name":    name,
+			"opening_balance": roundFloat(opening, 2),
+			"closing_balance": roundFloat(opening, 2),
+			"entries":         []map[string]any{},
+		}
+	}
+
+	statement["from"] = from
+	statement["to"] = to
+
+	ttl := closedRangeTTL
+	if to >= time.Now().Format(DateLayout) {
+		ttl = openRangeTTL
+	}
+	if statementJSON, err := json.Marshal(statement); err == nil {
+		redisutil.RedisClient.Set(ctx, key, statementJSON, ttl)
+	}
+	statement["cached"] = false
+	return withAging(caller, partnerID, statement)
+}
+
+func withAging(caller odoo.Caller, partnerID int, statement map[string]any) (map[string]any, error) {
+	aging, err := Aging(caller, partnerID)
+	if err != nil {
+		return nil, err
+	}
+	statement["aging"] = aging
+	return statement, nil
+}
+
+// Invalidate drops every cached range statement of the partner
+func Invalidate(ctx context.Context, partnerID int) error {
+	iter := redisutil.RedisClient.Scan(ctx, 0, fmt.Sprintf("statement:%d:*", partnerID), 100).Iterator()
+	keys := []string{}
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return redisutil.RedisClient.Del(ctx, keys...).Err()
+}
+
+func partnerName(caller odoo.Caller, partnerID int) (string, error) {
+	partners, err := searchRead(caller, "res.partner", []any{[]any{"id", "=", partnerID}}, []string{"display_name"}, "", 0, 1)
+	if err != nil {
+		return "", err
+	}
+	if len(partners) == 0 {
+		return "", fmt.Errorf("partner %d not found", partnerID)
+	}
+	return getString(partners[0]["display_name"]), nil
+}
@@ -0,0 +1,287 @@
+package statements
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+)
+
+// DateLayout is the Odoo date format statement ranges use
+const DateLayout = "2006-01-02"
+
+// lineBatchSize is how many ledger lines are held in memory at once
+const lineBatchSize = 5000
+
+var lineFields = []string{"id", "date", "partner_id", "debit", "credit", "move_id"}
+
+// receivableDomain selects posted receivable ledger lines
+func receivableDomain() []any {
+	return []any{
+		[]any{"partner_id", "!=", false},
+		[]any{"move_id.state", "=", "posted"},
+		[]any{"account_id.account_type", "=", "asset_receivable"},
+	}
+}
+
+// OpeningBalances sums debit - credit of receivable lines dated before `before`
+// per partner, with one read_group. A non-empty partnerIDs limits it to those partners.
+func OpeningBalances(caller odoo.Caller, partnerIDs []int, before string) (map[int]float64, error) {
+	domain := append(receivableDomain(), []any{"date", "<", before})
+	if len(partnerIDs) > 0 {
+		domain = append(domain, []any{"partner_id", "in", partnerIDs})
+	}
+
+	result, err := odoo.CallKW(caller, "account.move.line", "read_group",
+		[]any{domain, []string{"debit:sum", "credit:sum"}, []string{"partner_id"}},
+		map[string]any{"lazy": false},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []map[string]any
+	if err := json.Unmarshal(result, &groups); err != nil {
+		return nil, err
+	}
+
+	balances := make(map[int]float64, len(groups))
+	for _, group := range groups {
+		if partnerID := many2oneID(group["partner_id"]); partnerID > 0 {
+			balances[partnerID] = getFloat(group["debit"]) - getFloat(group["credit"])
+		}
+	}
+	return balances, nil
+}
+
+// StreamLines pages through receivable lines dated from..to (to may be empty),
+// grouped by partner server-side, and calls fn once per partner with that
+// partner's sorted lines and their moves. Memory holds one page plus one
+// partner's lines. A non-empty partnerIDs limits it to those partners.
+func StreamLines(caller odoo.Caller, partnerIDs []int, from, to string, fn func(partnerID int, lines []map[string]any, moves map[int]map[string]any) error) error {
+	domain := append(receivableDomain(), []any{"date", ">=", from})
+	if to != "" {
+		domain = append(domain, []any{"date", "<=", to})
+	}
+	if len(partnerIDs) > 0 {
+		domain = append(domain, []any{"partner_id", "in", partnerIDs})
+	}
+
+	currentPartner := 0
+	buffered := []map[string]any{}
+	bufferedMoves := make(map[int]map[string]any)
+	flush := func() error {
+		if len(buffered) == 0 {
+			return nil
+		}
+		sortLines(buffered)
+		if err := fn(currentPartner, buffered, bufferedMoves); err != nil {
+			return err
+		}
+		buffered = []map[string]any{}
+		bufferedMoves = make(map[int]map[string]any)
+		return nil
+	}
+
+	for offset := 0; ; offset += lineBatchSize {
+		page, err := searchRead(caller, "account.move.line", domain, lineFields, "partner_id, date, id", offset, lineBatchSize)
+		if err != nil {
+			return err
+		}
+
+		pageMoves, err := fetchMoves(caller, lineMoveIDs(page))
+		if err != nil {
+			return err
+		}
+
+		for _, line := range page {
+			partnerID := many2oneID(line["partner_id"])
+			if partnerID != currentPartner {
+				if err := flush(); err != nil {
+					return err
+				}
+				currentPartner = partnerID
+			}
+			buffered = append(buffered, line)
+			if moveID := many2oneID(line["move_id"]); pageMoves[moveID] != nil {
+				bufferedMoves[moveID] = pageMoves[moveID]
+			}
+		}
+
+		if len(page) < lineBatchSize {
+			return flush()
+		}
+	}
+}
+
+// Build builds one partner's statement from the opening balance and their
+// sorted in-period ledger lines, opening plus running balance per row. It
+// returns nil when the partner had no activity in the period.
+func Build(partnerID int, openingBalance float64, lines []map[string]any, moves map[int]map[string]any) map[string]any {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	partnerName := many2oneName(lines[0]["partner_id"])
+
+	runningBalance := 0.0
+	rows := []map[string]any{}
+
+	for _, line := range lines {
+		lineDate := getString(line["date"])
+		debit := getFloat(line["debit"])
+		credit := getFloat(line["credit"])
+		runningBalance += debit - credit
+
+		move := moves[many2oneID(line["move_id"])]
+
+		moveType := getString(move["move_type"])
+		invoiceID := getInt(move["id"])
+
+		var pdfURL any
+		if moveType == "out_invoice" || moveType == "out_refund" {
+			pdfURL = fmt.Sprintf("%s/report/pdf/account.report_invoice/%d", config.ConfigGlobal.OdooURL, invoiceID)
+		}
+
+		rows = append(rows, map[string]any{
+			"partner_id":      partnerID,
+			"partner_name":    partnerName,
+			"journal":         many2oneName(move["journal_id"]),
+			"invoice_id":      invoiceID,
+			"invoice_name":    getString(move["name"]),
+			"invoice_date":    lineDate,
+			"pdf_url":         pdfURL,
+			"debit":           roundFloat(debit, 2),
+			"credit":          roundFloat(credit, 2),
+			"running_balance": roundFloat(openingBalance+runningBalance, 2),
+		})
+	}
+
+	return map[string]any{
+		"partner_id":      partnerID,
+		"partner_name":    partnerName,
+		"opening_balance": roundFloat(openingBalance, 2),
+		"closing_balance": roundFloat(openingBalance+runningBalance, 2),
+		"entries":         rows,
+	}
+}
+
+// sortLines sorts ledger lines in Odoo order: date, move_id, id. Odoo would
+// order move_id by account.move's own _order, so this is done locally.
+func sortLines(lines []map[string]any) {
+	sort.Slice(lines, func(i, j int) bool {
+		dateI := getString(lines[i]["date"])
+		dateJ := getString(lines[j]["date"])
+		if dateI != dateJ {
+			return dateI < dateJ
+		}
+
+		moveIDI := many2oneID(lines[i]["move_id"])
+		moveIDJ := many2oneID(lines[j]["move_id"])
+		if moveIDI != moveIDJ {
+			return moveIDI < moveIDJ
+		}
+
+		return getInt(lines[i]["id"]) < getInt(lines[j]["id"])
+	})
+}
+
+// lineMoveIDs returns the distinct moves the ledger lines belong to
+func lineMoveIDs(lines []map[string]any) []int {
+	seen := make(map[int]bool)
+	moveIDs := []int{}
+	for _, line := range lines {
+		if moveID := many2oneID(line["move_id"]); moveID > 0 && !seen[moveID] {
+			seen[moveID] = true
+			moveIDs = append(moveIDs, moveID)
+		}
+	}
+	return moveIDs
+}
+
+// fetchMoves reads the account.move records the lines point at
+func fetchMoves(caller odoo.Caller, moveIDs []int) (map[int]map[string]any, error) {
+	moveMap := make(map[int]map[string]any)
+	if len(moveIDs) == 0 {
+		return moveMap, nil
+	}
+
+	moves, err := searchRead(caller, "account.move", []any{[]any{"id", "in", moveIDs}}, []string{"id", "name", "move_type", "journal_id"}, "", 0, len(moveIDs))
+	if err != nil {
+		return nil, err
+	}
+	for _, move := range moves {
+		moveMap[getInt(move["id"])] = move
+	}
+	return moveMap, nil
+}
+
+func searchRead(caller odoo.Caller, model string, domain []any, fields []string, order string, offset, limit int) ([]map[string]any, error) {
+	kwargs := map[string]any{
+		"fields": fields,
+		"offset": offset,
+	}
+	if limit > 0 {
+		kwargs["limit"] = limit
+	}
+	if order != "" {
+		kwargs["order"] = order
+	}
+
+	result, err := odoo.CallKW(caller, model, "search_read", []any{domain}, kwargs)
+	if err != nil {
+		return nil, err
+	}
+
+	var records []map[string]any
+	if err := json.Unmarshal(result, &records); err != nil {
+		return nil, err
+	}
+	return records, nil
+}
+
+// Helper functions
+func getInt(v any) int {
+	if f, ok := v.(float64); ok {
+		return int(f)
+	}
+	return 0
+}
+
+func getFloat(v any) float64 {
+	if f, ok := v.(float64); ok {
+		return f
+	}
+	return 0.0
+}
+
+func getString(v any) string {
+	if v == nil {
+		return ""
+	}
+	return fmt.Sprintf("%v", v)
+}
+
+func many2oneID(v any) int {
+	if list, ok := v.([]any); ok && len(list) > 0 {
+		return getInt(list[0])
+	}
+	return 0
+}
+
+func many2oneName(v any) string {
+	if list, ok := v.([]any); ok && len(list) > 1 {
+		return getString(list[1])
+	}
+	return ""
+}
+
+func roundFloat(val float64, precision int) float64 {
+	multiplier := 1.0
+	for i := 0; i < precision; i++ {
+		multiplier *= 10
+	}
+	return float64(int(val*multiplier+0.5)) / multiplier
+}
@@ -0,0 +1,40 @@
+package tasks
+
+import (
+	"encoding/json"
+	"math"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+const NotifyEmail = "notify:email"
+
+// EmailMaxRetry is how many times a message is retried before it is marked failed.
+// asynq passes RetryDelay the retries so far starting at 0, so the waits are
+// 30s, 1m, 2m, 4m, 8m, 16m, 32m, 64m, then 2h twice: about 6h10m in total.
+const EmailMaxRetry = 10
+
+// EmailPayload is the payload of a notify:email task; the message itself lives in the outbox
+type EmailPayload struct {
+	MessageID string `json:"message_id"`
+}
+
+// NotifyEmailTask delivers one outbox message. The task ID is the message ID,
+// so a message is never queued twice.
+func NotifyEmailTask(messageID string) *asynq.Task {
+	payload, _ := json.Marshal(EmailPayload{MessageID: messageID})
+	return asynq.NewTask(NotifyEmail, payload,
+		asynq.MaxRetry(EmailMaxRetry), asynq.TaskID("notify:email:"+messageID), asynq.Timeout(2*time.Minute))
+}
+
+// RetryDelay backs email off exponentially from 30s up to 2h, since SMTP
+// outages tend to last minutes rather than seconds. Everything else keeps
+// asynq's default.
+func RetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	if task.Type() == NotifyEmail {
+		delay := 30 * time.Second * time.Duration(math.Pow(2, float64(min(n, 8))))
+		return min(delay, 2*time.Hour)
+	}
+	return asynq.DefaultRetryDelayFunc(n, err, task)
+}
@@ -2,147 +2,73 @@ package tasks
 
 import (
 	"context"
+	"errors"
 	"log"
+	"strconv"
 	"time"
 
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	"github.com/hibiken/asynq"
 )
 
 const (
-	coreTasksCount  = 4
-	orderTasksCount = 2
+	FullSyncWorkflowName = "full_sync"
+	syncLockKey          = "sync_running"
+	// syncLeaseTTL only has to cover the gap between steps: running steps renew it
+	syncLeaseTTL = 10 * time.Minute
 )
 
-func RunFullSyncOrchestration(client *asynq.Client) error {
-	log.Println("🔄 Starting full sync orchestration...")
-
-	ctx := context.Background()
-	lockKey := "sync_running"
-
-	locked, err := redisutil.RedisClient.SetNX(ctx, lockKey, "1", 10*time.Minute).Result()
-	if err != nil {
-		log.Printf("❌ Failed to acquire sync lock: %v", err)
-		return err
+// FullSyncWorkflow is the hourly sync as a DAG: the core entities run in
+// parallel, orders and invoices start as soon as customers are in.
+// SYNC_FAILURE_POLICY (abort, continue, retry_group) and SYNC_GROUP_RETRIES tune failures.
+func FullSyncWorkflow() *Workflow {
+	policy := FailurePolicy(config.ConfigGlobal.SyncFailurePolicy)
+	switch policy {
+	case FailAbort, FailContinue, FailRetryGroup:
+	default:
+		policy = FailContinue
 	}
-
-	if !locked {
-		log.Println("⚠️  Lock exists, skipping the sync")
-		return nil
-	}
-
-	// Initialize core tasks completion counter
-	coreTasksKey := "core_tasks_remaining"
-	if err := redisutil.RedisClient.Set(ctx, coreTasksKey, coreTasksCount, 0).Err(); err != nil {
-		log.Printf("❌ Failed to set core tasks counter: %v", err)
-		redisutil.RedisClient.Del(ctx, lockKey)
-		return err
-	}
-
-	// Enqueue core tasks
-	coreTasks := []*asynq.Task{
-		SyncProductsTask(),
-		SyncCustomersTask(),
-		SyncPricelistsTask(),
-		SyncCustomerStatementsTask(),
+	groupRetries, err := strconv.Atoi(config.ConfigGlobal.SyncGroupRetries)
+	if err != nil {
+		groupRetries = 1
 	}
 
-	for _, task := range coreTasks {
-		if _, err := client.Enqueue(task); err != nil {
-			log.Printf("❌ Failed to enqueue core task: %v", err)
-			redisutil.RedisClient.Del(ctx, lockKey)
-			redisutil.RedisClient.Del(ctx, coreTasksKey)
-			return err
-		}
+	return &Workflow{
+		Name: FullSyncWorkflowName,
+		Steps: []Step{
+			{Name: SyncProducts, Group: "core"},
+			{Name: SyncCustomers, Group: "core"},
+			{Name: SyncPricelists, Group: "core"},
+			{Name: SyncCustomerStatements, Group: "core"},
+			{Name: SyncOrders, Group: "orders", DependsOn: []string{SyncCustomers}},
+			{Name: SyncInvoicesAndLines, Group: "orders", DependsOn: []string{SyncCustomers}},
+			{Name: SyncPayments, Group: "orders", DependsOn: []string{SyncCustomers}},
+			{Name: SyncDeliveries, Group: "orders", DependsOn: []string{SyncOrders}},
+		},
+		OnFailure:       policy,
+		MaxGroupRetries: groupRetries,
+		LockKey:         syncLockKey,
+		LockTTL:         syncLeaseTTL,
+		OnFinish: func(ctx context.Context, runID, status string) {
+			log.Printf("✅ Full sync completed! (%s, run %s)", status, runID)
+		},
 	}
+}
 
-	// Poll for core tasks completion (with timeout)
-	timeout := 15 * time.Minute
-	checkInterval := 2 * time.Second
-	startTime := time.Now()
-
-	for {
-		if time.Since(startTime) > timeout {
-			log.Printf("❌ Timeout waiting for core tasks to complete")
-			redisutil.RedisClient.Del(ctx, lockKey)
-			redisutil.RedisClient.Del(ctx, coreTasksKey)
-			return nil
-		}
-
-		remaining, err := redisutil.RedisClient.Get(ctx, coreTasksKey).Int()
-		if err != nil {
-			// Key doesn't exist or error - assume tasks completed
-			break
-		}
-
-		if remaining == 0 {
-			log.Println("✅ All core tasks completed!")
-			redisutil.RedisClient.Del(ctx, coreTasksKey)
-			break
-		}
+// RunFullSyncOrchestration starts the full sync workflow, which holds the sync
+// lease until its last step finishes. It returns right away.
+func RunFullSyncOrchestration(ctx context.Context, client *asynq.Client) error {
+	log.Println("🔄 Starting full sync orchestration...")
 
-		time.Sleep(checkInterval)
+	_, err := StartWorkflow(ctx, client, FullSyncWorkflowName)
+	if errors.Is(err, redisutil.ErrLockHeld) {
+		log.Println("⚠️  Lock exists, skipping the sync")
+		return nil
 	}
-
-	// Initialize order tasks completion counter
-	orderTasksKey := "order_tasks_remaining"
-	if err := redisutil.RedisClient.Set(ctx, orderTasksKey, orderTasksCount, 0).Err(); err != nil {
-		log.Printf("❌ Failed to set order tasks counter: %v", err)
-		redisutil.RedisClient.Del(ctx, lockKey)
+	if err != nil {
+		log.Printf("❌ Failed to start full sync workflow: %v", err)
 		return err
 	}
-
-	// Enqueue order tasks group
-	log.Println("📦 Group 2: Order Tasks - Enqueuing tasks in parallel...")
-	log.Println("   - sync:orders")
-	log.Println("   - sync:invoices_and_lines")
-
-	orderTasks := []*asynq.Task{
-		SyncOrdersTask(),
-		SyncInvoicesAndLinesTask(),
-	}
-
-	for _, task := range orderTasks {
-		if _, err := client.Enqueue(task); err != nil {
-			log.Printf("❌ Failed to enqueue order task: %v", err)
-			redisutil.RedisClient.Del(ctx, lockKey)
-			redisutil.RedisClient.Del(ctx, orderTasksKey)
-			return err
-		}
-	}
-
-	log.Printf("✅ Order tasks group enqueued - %d tasks", len(orderTasks))
-
-	// Poll for order tasks completion (with timeout)
-	orderTimeout := 20 * time.Minute
-	orderStartTime := time.Now()
-
-	for {
-		if time.Since(orderStartTime) > orderTimeout {
-			log.Printf("❌ Timeout waiting for order tasks to complete")
-			redisutil.RedisClient.Del(ctx, lockKey)
-			redisutil.RedisClient.Del(ctx, orderTasksKey)
-			return nil
-		}
-
-		remaining, err := redisutil.RedisClient.Get(ctx, orderTasksKey).Int()
-		if err != nil {
-			// Key doesn't exist or error - assume tasks completed
-			break
-		}
-
-		if remaining == 0 {
-			log.Println("✅ All order tasks completed!")
-			redisutil.RedisClient.Del(ctx, orderTasksKey)
-			break
-		}
-
-		time.Sleep(checkInterval)
-	}
-
-	// All tasks completed - release lock and log final message
-	redisutil.RedisClient.Del(ctx, lockKey)
-	log.Println("✅ Full sync completed!")
-
 	return nil
 }
@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/hibiken/asynq"
+)
+
+// SyncRecords resyncs individual records of one Odoo model, pushed by the webhook
+const SyncRecords = "sync:records"
+
+// RecordSyncModels are the Odoo models a targeted sync can refresh
+var RecordSyncModels = map[string]bool{
+	"product.product":        true,
+	"product.template":       true,
+	"res.partner":            true,
+	"product.pricelist.item": true,
+	"sale.order":             true,
+	"account.move":           true,
+}
+
+// pendingRecordsTTL keeps ids around long enough to survive a worker outage
+const pendingRecordsTTL = 24 * time.Hour
+
+// RecordSyncPayload is the payload of a sync:records task
+type RecordSyncPayload struct {
+	Model string `json:"model"`
+}
+
+// PendingRecordsKey is the set of record ids of a model waiting for a targeted sync
+func PendingRecordsKey(model string) string {
+	return fmt.Sprintf("sync:records:pending:%s", model)
+}
+
+// EnqueueRecordSync queues ids for a targeted sync. Ids collect in a pending set
+// and one task per model and debounce window picks them all up at the end of
+// the window, so a burst of webhook calls becomes a single resync.
+func EnqueueRecordSync(ctx context.Context, client *asynq.Client, model string, ids []int, debounce time.Duration) error {
+	if !RecordSyncModels[model] {
+		return fmt.Errorf("model %q can't be synced by id", model)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	members := make([]any, len(ids))
+	for i, id := range ids {
+		members[i] = id
+	}
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.SAdd(ctx, PendingRecordsKey(model), members...)
+	pipe.Expire(ctx, PendingRecordsKey(model), pendingRecordsTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	window := time.Now().Truncate(debounce).Add(debounce)
+	payload, _ := json.Marshal(RecordSyncPayload{Model: model})
+	_, err := client.EnqueueContext(ctx, asynq.NewTask(SyncRecords, payload, asynq.MaxRetry(3)),
+		asynq.TaskID(fmt.Sprintf("%s:%s:%d", SyncRecords, model, window.Unix())),
+		asynq.ProcessAt(window),
+	)
+	// The window's task is already queued and will take these ids too
+	if errors.Is(err, asynq.ErrTaskIDConflict) {
+		return nil
+	}
+	return err
+}
+
+// claimedRecordsKey holds the ids a sync:records task took from the pending set
+func claimedRecordsKey(model, taskID string) string {
+	return fmt.Sprintf("sync:records:claimed:%s:%s", model, taskID)
+}
+
+// ClaimPendingRecords moves the pending ids of the model to the task, so ids
+// pushed while it runs wait for the next window. A retry gets the same ids back.
+func ClaimPendingRecords(ctx context.Context, model, taskID string) ([]int, error) {
+	claimed := claimedRecordsKey(model, taskID)
+	err := redisutil.RedisClient.RenameNX(ctx, PendingRecordsKey(model), claimed).Err()
+	if err != nil && err.Error() != "ERR no such key" {
+		return nil, err
+	}
+
+	members, err := redisutil.RedisClient.SMembers(ctx, claimed).Result()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]int, 0, len(members))
+	for _, member := range members {
+		if id, err := strconv.Atoi(member); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// ReleaseClaimedRecords drops the task's claimed ids once they were synced
+func ReleaseClaimedRecords(ctx context.Context, model, taskID string) error {
+	return redisutil.RedisClient.Del(ctx, claimedRecordsKey(model, taskID)).Err()
+}
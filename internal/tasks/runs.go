@@ -0,0 +1,58 @@
+package tasks
+
+import (
+	"fmt"
+	"time"
+
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+)
+
+// Sync run history. Every sync:* task attempt writes a RunRecord to
+// sync:run:{run_id}; sync:runs and sync:runs:{entity} index them by start time,
+// and the latest / last successful run per entity are kept in hashes.
+const (
+	SyncRunsKey             = "sync:runs"
+	SyncRunsLatestKey       = "sync:runs:latest"
+	SyncRunsLastOKKey       = "sync:runs:last_success"
+	DefaultRunRetentionDays = 14
+)
+
+const (
+	RunRunning   = "running"
+	RunSucceeded = "succeeded"
+	RunFailed    = "failed"
+)
+
+// RunRecord is the structured summary of one sync task attempt
+type RunRecord struct {
+	RunID             string                       `json:"run_id"`
+	TaskID            string                       `json:"task_id"`
+	Task              string                       `json:"task"`
+	Entity            string                       `json:"entity"`
+	Attempt           int                          `json:"attempt"`
+	WorkflowRunID     string                       `json:"workflow_run_id,omitempty"`
+	Status            string                       `json:"status"`
+	StartedAt         time.Time                    `json:"started_at"`
+	FinishedAt        *time.Time                   `json:"finished_at,omitempty"`
+	DurationMs        int64                        `json:"duration_ms"`
+	PagesFetched      int64                        `json:"pages_fetched"`
+	RecordsProcessed  int64                        `json:"records_processed"`
+	RecordsSkipped    int64                        `json:"records_skipped"`
+	Items             int64                        `json:"items,omitempty"`
+	RedisKeysWritten  int64                        `json:"redis_keys_written"`
+	TypesenseImported int64                        `json:"typesense_imported"`
+	TypesenseFailed   int64                        `json:"typesense_failed"`
+	OdooCalls         int64                        `json:"odoo_calls"`
+	Imports           []typesenseutil.ImportReport `json:"imports,omitempty"`
+	Error             string                       `json:"error,omitempty"`
+}
+
+// SyncRunKey holds the JSON RunRecord of one run
+func SyncRunKey(runID string) string {
+	return fmt.Sprintf("sync:run:%s", runID)
+}
+
+// SyncEntityRunsKey indexes the runs of one entity by start time
+func SyncEntityRunsKey(entity string) string {
+	return fmt.Sprintf("sync:runs:%s", entity)
+}
@@ -0,0 +1,186 @@
+package tasks
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/hibiken/asynq"
+	"gopkg.in/yaml.v3"
+)
+
+const defaultScheduleFile = "go-worker/schedules.yaml"
+
+// ScheduleEntry is the schedule of one task type. Unset fields keep the default.
+type ScheduleEntry struct {
+	Task     string        `yaml:"task"`
+	Cron     string        `yaml:"cron"`
+	Timeout  time.Duration `yaml:"timeout"`
+	MaxRetry *int          `yaml:"max_retry"`
+	Queue    string        `yaml:"queue"`
+	Enabled  *bool         `yaml:"enabled"`
+}
+
+type scheduleFile struct {
+	Schedules []ScheduleEntry `yaml:"schedules"`
+}
+
+// defaultSchedules is what runs without any configuration: the hourly full
+// sync and the nightly reconciliation
+func defaultSchedules() []ScheduleEntry {
+	return []ScheduleEntry{
+		{Task: OrchestrateFullSync, Cron: "0 * * * *"},
+		{Task: SyncReconcile, Cron: "30 2 * * *"},
+	}
+}
+
+// schedulableTasks are the task types a schedule may name
+var schedulableTasks = map[string]bool{
+	SyncProducts:           true,
+	SyncCustomers:          true,
+	SyncPricelists:         true,
+	SyncCustomerStatements: true,
+	SyncOrders:             true,
+	SyncInvoicesAndLines:   true,
+	SyncReconcile:          true,
+	SyncStock:              true,
+	SyncPayments:           true,
+	SyncDeliveries:         true,
+	OrchestrateFullSync:    true,
+}
+
+// LoadSchedules merges, per task type, the defaults with the YAML file
+// (SYNC_SCHEDULE_FILE, default go-worker/schedules.yaml) and then the inline
+// YAML or JSON in SYNC_SCHEDULES
+func LoadSchedules() ([]ScheduleEntry, error) {
+	entries := defaultSchedules()
+
+	path := config.ConfigGlobal.SyncScheduleFile
+	if path == "" {
+		path = defaultScheduleFile
+	}
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if entries, err = mergeSchedules(entries, data); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case !errors.Is(err, os.ErrNotExist) || config.ConfigGlobal.SyncScheduleFile != "":
+		return nil, err
+	}
+
+	if inline := config.ConfigGlobal.SyncSchedules; inline != "" {
+		if entries, err = mergeSchedules(entries, []byte(inline)); err != nil {
+			return nil, fmt.Errorf("SYNC_SCHEDULES: %w", err)
+		}
+	}
+	return entries, nil
+}
+
+func mergeSchedules(entries []ScheduleEntry, data []byte) ([]ScheduleEntry, error) {
+	var file scheduleFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for _, override := range file.Schedules {
+		if !schedulableTasks[override.Task] {
+			return nil, fmt.Errorf("unknown task type %q", override.Task)
+		}
+
+		merged := false
+		for i := range entries {
+			if entries[i].Task != override.Task {
+				continue
+			}
+			if override.Cron != "" {
+				entries[i].Cron = override.Cron
+			}
+			if override.Timeout != 0 {
+				entries[i].Timeout = override.Timeout
+			}
+			if override.MaxRetry != nil {
+				entries[i].MaxRetry = override.MaxRetry
+			}
+			if override.Queue != "" {
+				entries[i].Queue = override.Queue
+			}
+			if override.Enabled != nil {
+				entries[i].Enabled = override.Enabled
+			}
+			merged = true
+		}
+		if !merged {
+			if override.Cron == "" {
+				return nil, fmt.Errorf("%s: cron is required", override.Task)
+			}
+			entries = append(entries, override)
+		}
+	}
+	return entries, nil
+}
+
+// IsEnabled is true unless the entry was switched off
+func (entry ScheduleEntry) IsEnabled() bool {
+	return entry.Enabled == nil || *entry.Enabled
+}
+
+// Options turns the entry into asynq task options
+func (entry ScheduleEntry) Options() []asynq.Option {
+	maxRetry := 3
+	if entry.MaxRetry != nil {
+		maxRetry = *entry.MaxRetry
+	}
+	opts := []asynq.Option{asynq.MaxRetry(maxRetry)}
+	if entry.Timeout > 0 {
+		opts = append(opts, asynq.Timeout(entry.Timeout))
+	}
+	if entry.Queue != "" {
+		opts = append(opts, asynq.Queue(entry.Queue))
+	}
+	return opts
+}
+
+// ScheduleProvider feeds the schedule to asynq's PeriodicTaskManager, which
+// re-reads it on every sync interval, so edits apply without a restart
+type ScheduleProvider struct{}
+
+func (ScheduleProvider) GetConfigs() ([]*asynq.PeriodicTaskConfig, error) {
+	entries, err := LoadSchedules()
+	if err != nil {
+		log.Printf("❌ Invalid sync schedule, keeping the previous one: %v", err)
+		return nil, err
+	}
+
+	configs := []*asynq.PeriodicTaskConfig{}
+	for _, entry := range entries {
+		if !entry.IsEnabled() {
+			continue
+		}
+		configs = append(configs, &asynq.PeriodicTaskConfig{
+			Cronspec: entry.Cron,
+			Task:     asynq.NewTask(entry.Task, nil),
+			Opts:     entry.Options(),
+		})
+	}
+	return configs, nil
+}
+
+// ScheduleQueues returns the queues the worker serves: critical, default and
+// any queue named in the schedule config
+func ScheduleQueues() map[string]int {
+	queues := map[string]int{CriticalQueue: 20, "default": 10}
+	entries, err := LoadSchedules()
+	if err != nil {
+		return queues
+	}
+	for _, entry := range entries {
+		if entry.Queue != "" && queues[entry.Queue] == 0 {
+			queues[entry.Queue] = 5
+		}
+	}
+	return queues
+}
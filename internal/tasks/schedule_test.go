@@ -0,0 +1,139 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMergeSchedules(t *testing.T) {
+	tests := []struct {
+		name    string
+		yaml    string
+		wantErr string
+		check   func(t *testing.T, entries []ScheduleEntry)
+	}{
+		{
+			name: "empty file keeps the defaults",
+			yaml: "",
+			check: func(t *testing.T, entries []ScheduleEntry) {
+				if len(entries) != len(defaultSchedules()) {
+					t.Errorf("got %d entries, want the %d defaults", len(entries), len(defaultSchedules()))
+				}
+			},
+		},
+		{
+			name: "override changes only the fields it sets",
+			yaml: `
+schedules:
+  - task: sync:orchestrate_full
+    cron: "*/30 * * * *"
+    timeout: 45m
+`,
+			check: func(t *testing.T, entries []ScheduleEntry) {
+				entry := findEntry(t, entries, OrchestrateFullSync)
+				if entry.Cron != "*/30 * * * *" || entry.Timeout != 45*time.Minute {
+					t.Errorf("got cron %q timeout %s", entry.Cron, entry.Timeout)
+				}
+				if entry.MaxRetry != nil || entry.Queue != "" || !entry.IsEnabled() {
+					t.Errorf("unset fields changed: %+v", entry)
+				}
+			},
+		},
+		{
+			name: "default can be switched off",
+			yaml: `
+schedules:
+  - task: sync:reconcile
+    enabled: false
+`,
+			check: func(t *testing.T, entries []ScheduleEntry) {
+				entry := findEntry(t, entries, SyncReconcile)
+				if entry.IsEnabled() {
+					t.Error("reconcile still enabled")
+				}
+				if entry.Cron != "30 2 * * *" {
+					t.Errorf("cron = %q, want the default", entry.Cron)
+				}
+			},
+		},
+		{
+			name: "new task is appended",
+			yaml: `
+schedules:
+  - task: sync:stock
+    cron: "*/5 * * * *"
+    max_retry: 0
+    queue: critical
+`,
+			check: func(t *testing.T, entries []ScheduleEntry) {
+				entry := findEntry(t, entries, SyncStock)
+				if entry.Cron != "*/5 * * * *" || entry.MaxRetry == nil || *entry.MaxRetry != 0 || entry.Queue != CriticalQueue {
+					t.Errorf("got %+v", entry)
+				}
+			},
+		},
+		{
+			name:    "new task needs a cron",
+			yaml:    "schedules:\n  - task: sync:stock\n    timeout: 1m\n",
+			wantErr: "cron is required",
+		},
+		{
+			name:    "unknown task is rejected",
+			yaml:    "schedules:\n  - task: sync:everything\n    cron: \"* * * * *\"\n",
+			wantErr: "unknown task type",
+		},
+		{
+			name:    "invalid yaml is rejected",
+			yaml:    "schedules: [",
+			wantErr: "yaml",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entries, err := mergeSchedules(defaultSchedules(), []byte(tt.yaml))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			tt.check(t, entries)
+		})
+	}
+}
+
+func TestScheduleEntryOptions(t *testing.T) {
+	zero := 0
+	tests := []struct {
+		name  string
+		entry ScheduleEntry
+		want  int
+	}{
+		{"defaults to three retries", ScheduleEntry{}, 1},
+		{"timeout and queue add options", ScheduleEntry{Timeout: time.Minute, Queue: "low"}, 3},
+		{"explicit zero retries", ScheduleEntry{MaxRetry: &zero}, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := len(tt.entry.Options()); got != tt.want {
+				t.Errorf("got %d options, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func findEntry(t *testing.T, entries []ScheduleEntry, task string) ScheduleEntry {
+	t.Helper()
+	for _, entry := range entries {
+		if entry.Task == task {
+			return entry
+		}
+	}
+	t.Fatalf("no schedule for %s", task)
+	return ScheduleEntry{}
+}
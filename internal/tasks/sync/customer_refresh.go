@@ -0,0 +1,157 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/statements"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+// HandleCustomerRefreshTask rebuilds one customer's record, statement and
+// invoices from Odoo, for a rep who just collected a payment
+func HandleCustomerRefreshTask(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.CustomerRefreshPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil || payload.PartnerID <= 0 {
+		return fmt.Errorf("invalid customer refresh payload: %w", asynq.SkipRetry)
+	}
+	partnerID := payload.PartnerID
+	log.Printf("🔄 Refreshing customer %d...", partnerID)
+	startTime := time.Now()
+
+	found, err := refreshCustomerRecord(ctx, partnerID)
+	if err != nil {
+		log.Printf("❌ Failed to refresh customer %d: %v", partnerID, err)
+		return err
+	}
+	if !found {
+		redisutil.RedisClient.Del(ctx, statements.DashboardKey(partnerID))
+		log.Printf("🗑️  Customer %d is no longer a live customer, removed", partnerID)
+		return nil
+	}
+
+	if err := refreshCustomerStatement(ctx, partnerID); err != nil {
+		log.Printf("❌ Failed to refresh statement of customer %d: %v", partnerID, err)
+		return err
+	}
+
+	if err := refreshCustomerInvoices(ctx, partnerID); err != nil {
+		log.Printf("❌ Failed to refresh invoices of customer %d: %v", partnerID, err)
+		return err
+	}
+
+	log.Printf("✅ Customer %d refreshed in %.2fs", partnerID, time.Since(startTime).Seconds())
+	return nil
+}
+
+// refreshCustomerRecord rewrites customers:{id}, its page entry and Typesense
+// document, or removes them when the partner is no longer a customer
+func refreshCustomerRecord(ctx context.Context, partnerID int) (bool, error) {
+	stats := runStatsFrom(ctx)
+	customers, err := odooSearchRead(ctx, "res.partner", []any{
+		[]any{"customer_rank", ">", 0},
+		[]any{"id", "=", partnerID},
+	}, customerFields, 100)
+	if err != nil {
+		return false, err
+	}
+	if len(customers) == 0 {
+		return false, removeCustomers(ctx, []string{strconv.Itoa(partnerID)})
+	}
+
+	customerDoc := cleanCustomer(customers[0])
+	saveCustomerHash(ctx, customerDoc)
+	stats.processed(1)
+	if customerPages.hasGeneration(ctx) {
+		if err := customerPages.upsert(ctx, []map[string]any{customerDoc}); err != nil {
+			return true, err
+		}
+	}
+
+	report, err := typesenseutil.ImportDocuments(ctx, "customers", currentRunID(ctx), []any{customerDoc}, api.IndexAction("upsert"))
+	stats.imported(report)
+	return true, err
+}
+
+// refreshCustomerStatement rebuilds dashboard:customer_statement:{id} the same
+// way the full statement sync does, and drops its cached range statements
+func refreshCustomerStatement(ctx context.Context, partnerID int) error {
+	if err := statements.Invalidate(ctx, partnerID); err != nil {
+		log.Printf("⚠️  Failed to drop cached statements of customer %d: %v", partnerID, err)
+	}
+
+	periodStart := statementPeriodStart()
+	openingBalances, err := statements.OpeningBalances(runCaller{ctx}, []int{partnerID}, periodStart)
+	if err != nil {
+		return err
+	}
+
+	var statement map[string]any
+	err = statements.StreamLines(runCaller{ctx}, []int{partnerID}, periodStart, "", func(_ int, lines []map[string]any, moves map[int]map[string]any) error {
+		statement = statements.Build(partnerID, openingBalances[partnerID], lines, moves)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if statement == nil {
+		return redisutil.RedisClient.Del(ctx, statements.DashboardKey(partnerID)).Err()
+	}
+	return saveCustomerStatement(ctx, partnerID, statement)
+}
+
+// refreshCustomerInvoices rewrites the partner's invoices in the sync window
+// with their lines, and drops the ones no longer posted. Invoices addressed to
+// the customer's contacts count too, the same way aging groups them.
+func refreshCustomerInvoices(ctx context.Context, partnerID int) error {
+	stats := runStatsFrom(ctx)
+	windowStart := time.Now().UTC().AddDate(0, 0, -180)
+	invoices, err := odooSearchRead(ctx, "account.move", []any{
+		invoiceTypeDomain(),
+		[]any{"commercial_partner_id", "=", partnerID},
+		[]any{"invoice_date", ">", windowStart.Format("2006-01-02")},
+	}, invoiceFields, 1000)
+	if err != nil {
+		return err
+	}
+
+	kept := []int{}
+	removed := []int{}
+	documents := []any{}
+	for _, inv := range invoices {
+		invoiceID := getInt(inv["id"])
+		if state, _ := inv["state"].(string); state != "posted" {
+			removed = append(removed, invoiceID)
+			continue
+		}
+		invoiceDoc, _ := cleanInvoice(inv)
+		saveInvoiceHeader(ctx, invoiceDoc)
+		kept = append(kept, invoiceID)
+		documents = append(documents, invoiceDoc)
+	}
+	stats.processed(len(kept))
+
+	removeInvoices(ctx, removed)
+	if _, err := mergeInvoiceIDs(ctx, kept, removed); err != nil {
+		return err
+	}
+	syncInvoiceLines(ctx, kept)
+
+	if len(documents) > 0 {
+		report, err := typesenseutil.ImportDocuments(ctx, "invoices", currentRunID(ctx), documents, api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
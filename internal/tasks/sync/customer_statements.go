@@ -5,195 +5,109 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
-	"sort"
 	"time"
 
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/statements"
 	"github.com/hibiken/asynq"
 )
 
+// HandleSyncCustomerStatementsTask builds every customer's 6 month statement.
+// Opening balances come from one read_group up to the period start, and only
+// in-period lines are streamed, ordered by partner server-side, so memory holds
+// one page plus one partner's lines however long the ledger history gets.
 func HandleSyncCustomerStatementsTask(ctx context.Context, t *asynq.Task) error {
-	defer MarkCoreTaskCompletion(ctx)
 	log.Println("🔄 Syncing customer statements (ledger-based, 6 months)...")
+	stats := runStatsFrom(ctx)
 
 	// Calculate period start (6 months ago)
-	today := time.Now()
-	periodStart := today.AddDate(0, -6, 0)
-	periodStartStr := periodStart.Format("2006-01-02")
+	periodStartStr := statementPeriodStart()
 
 	log.Printf("📅 Statement period starts from: %s", periodStartStr)
 
-	// 1. Fetch ALL posted receivable ledger lines
-	ledgerLines, err := odooSearchRead(
-		"account.move.line",
-		[]any{
-			[]any{"partner_id", "!=", false},
-			[]any{"move_id.state", "=", "posted"},
-			[]any{"account_id.account_type", "=", "asset_receivable"},
-		},
-		[]string{"id", "date", "partner_id", "debit", "credit", "move_id"},
-		5000,
-	)
+	// 1. Opening balance per partner, summed by Odoo
+	openingBalances, err := statements.OpeningBalances(runCaller{ctx}, nil, periodStartStr)
 	if err != nil {
-		log.Printf("❌ Failed to fetch ledger lines: %v", err)
+		log.Printf("❌ Failed to fetch opening balances: %v", err)
 		return err
 	}
+	log.Printf("✅ Retrieved opening balances for %d partners", len(openingBalances))
 
-	log.Printf("✅ Retrieved %d ledger lines for customer statements", len(ledgerLines))
+	// 2. Stream in-period lines partner by partner and store each statement
+	processedCount, skippedCount := 0, 0
+	store := func(partnerID int, lines []map[string]any, moves map[int]map[string]any) error {
+		customerStatement := statements.Build(partnerID, openingBalances[partnerID], lines, moves)
 
-	if len(ledgerLines) == 0 {
-		log.Println("✅ No ledger lines found")
-		return nil
-	}
-
-	// 2. Sort ledger lines (Odoo order: date, move_id, id)
-	sort.Slice(ledgerLines, func(i, j int) bool {
-		dateI := getString(ledgerLines[i]["date"])
-		dateJ := getString(ledgerLines[j]["date"])
-		if dateI != dateJ {
-			return dateI < dateJ
-		}
-
-		moveIDI := getMoveID(ledgerLines[i]["move_id"])
-		moveIDJ := getMoveID(ledgerLines[j]["move_id"])
-		if moveIDI != moveIDJ {
-			return moveIDI < moveIDJ
+		// Skip customers with no activity in last 6 months
+		if customerStatement == nil {
+			skippedCount++
+			return nil
 		}
 
-		idI := getInt(ledgerLines[i]["id"])
-		idJ := getInt(ledgerLines[j]["id"])
-		return idI < idJ
-	})
-
-	// 3. Fetch related moves
-	moveIDs := make(map[int]bool)
-	for _, line := range ledgerLines {
-		if moveID := getMoveID(line["move_id"]); moveID > 0 {
-			moveIDs[moveID] = true
+		// 3. Store per partner in Redis
+		if err := saveCustomerStatement(ctx, partnerID, customerStatement); err != nil {
+			log.Printf("⚠️  Failed to save statement for partner %d: %v", partnerID, err)
+			skippedCount++
+			return nil
 		}
-	}
 
-	moveIDList := make([]int, 0, len(moveIDs))
-	for id := range moveIDs {
-		moveIDList = append(moveIDList, id)
+		processedCount++
+		return nil
 	}
 
-	moves, err := fetchMoves(moveIDList)
-	if err != nil {
-		log.Printf("❌ Failed to fetch moves: %v", err)
+	if err := statements.StreamLines(runCaller{ctx}, nil, periodStartStr, "", store); err != nil {
+		log.Printf("❌ Failed to stream ledger lines: %v", err)
 		return err
 	}
 
-	// 4. Group ledger lines per customer
-	partnerGroups := make(map[int][]map[string]any)
-	for _, line := range ledgerLines {
-		partnerID := getPartnerID(line["partner_id"])
-		if partnerID > 0 {
-			partnerGroups[partnerID] = append(partnerGroups[partnerID], line)
-		}
-	}
-
-	// 5. Build statement per customer (6 months logic)
-	processedCount := 0
-	for partnerID, lines := range partnerGroups {
-		if len(lines) == 0 {
-			continue
-		}
-
-		partnerName := getPartnerName(lines[0]["partner_id"])
-
-		openingBalance := 0.0
-		runningBalance := 0.0
-		rows := []map[string]any{}
-
-		for _, line := range lines {
-			lineDate := getString(line["date"])
-			debit := getFloat(line["debit"])
-			credit := getFloat(line["credit"])
-			delta := debit - credit
-
-			// BEFORE period → opening balance
-			if lineDate < periodStartStr {
-				openingBalance += delta
-				continue
-			}
-
-			// INSIDE period → statement row
-			runningBalance += delta
+	stats.processed(processedCount)
+	stats.skipped(skippedCount)
+	stats.items(int64(processedCount))
 
-			moveID := getMoveID(line["move_id"])
-			move := moves[moveID]
-
-			moveType := getString(move["move_type"])
-			invoiceID := getInt(move["id"])
-
-			var pdfURL any
-			if moveType == "out_invoice" || moveType == "out_refund" {
-				pdfURL = fmt.Sprintf("%s/report/pdf/account.report_invoice/%d", config.ConfigGlobal.OdooURL, invoiceID)
-			} else {
-				pdfURL = nil
-			}
-
-			journal := ""
-			if journalID, ok := move["journal_id"].([]any); ok && len(journalID) > 1 {
-				journal = getString(journalID[1])
-			}
-
-			rows = append(rows, map[string]any{
-				"partner_id":    partnerID,
-				"partner_name":  partnerName,
-				"journal":       journal,
-				"invoice_id":    invoiceID,
-				"invoice_name":  getString(move["name"]),
-				"invoice_date":  lineDate,
-				"pdf_url":       pdfURL,
-				"debit":         roundFloat(debit, 2),
-				"credit":        roundFloat(credit, 2),
-				"running_balance": roundFloat(openingBalance+runningBalance, 2),
-			})
-		}
-
-		// Skip customers with no activity in last 6 months
-		if len(rows) == 0 {
-			continue
-		}
-
-		customerStatement := map[string]any{
-			"partner_id":      partnerID,
-			"partner_name":    partnerName,
-			"opening_balance": roundFloat(openingBalance, 2),
-			"closing_balance": roundFloat(openingBalance+runningBalance, 2),
-			"entries":         rows,
-		}
-
-		// 6. Store per partner in Redis
-		redisKey := fmt.Sprintf("dashboard:customer_statement:%d", partnerID)
-		statementJSON, err := json.Marshal(customerStatement)
-		if err != nil {
-			log.Printf("⚠️  Failed to marshal statement for partner %d: %v", partnerID, err)
-			continue
-		}
+	log.Printf("✅ Customer statements synced successfully - %d customers processed", processedCount)
+	return nil
+}
 
-		if err := redisutil.RedisClient.Set(ctx, redisKey, statementJSON, 0).Err(); err != nil {
-			log.Printf("⚠️  Failed to save statement for partner %d: %v", partnerID, err)
-			continue
-		}
+// statementPeriodStart is the first day statements list entries for, 6 months ago
+func statementPeriodStart() string {
+	return time.Now().AddDate(0, -6, 0).Format("2006-01-02")
+}
 
-		processedCount++
+func saveCustomerStatement(ctx context.Context, partnerID int, statement map[string]any) error {
+	statementJSON, err := json.Marshal(statement)
+	if err != nil {
+		return err
 	}
-
-	log.Printf("✅ Customer statements synced successfully - %d customers processed", processedCount)
-	return nil
+	return redisutil.RedisClient.Set(ctx, statements.DashboardKey(partnerID), statementJSON, 0).Err()
 }
 
 // odooSearchRead fetches data from Odoo in batches
-func odooSearchRead(model string, domain []any, fields []string, batchSize int) ([]map[string]any, error) {
-	offset := 0
+func odooSearchRead(ctx context.Context, model string, domain []any, fields []string, batchSize int) ([]map[string]any, error) {
 	results := []map[string]any{}
+	err := odooSearchReadPages(ctx, model, domain, fields, "", batchSize, func(page []map[string]any) error {
+		results = append(results, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// odooSearchReadPages fetches data from Odoo in batches and hands each page to fn
+// without keeping it. An empty order keeps Odoo's default order.
+func odooSearchReadPages(ctx context.Context, model string, domain []any, fields []string, order string, batchSize int, fn func(page []map[string]any) error) error {
+	offset := 0
+	kwargs := map[string]any{
+		"fields": fields,
+		"limit":  batchSize,
+	}
+	if order != "" {
+		kwargs["order"] = order
+	}
 
 	for {
+		kwargs["offset"] = offset
 		payload := map[string]any{
 			"jsonrpc": "2.0",
 			"method":  "call",
@@ -201,64 +115,43 @@ func odooSearchRead(model string, domain []any, fields []string, batchSize int)
 				"model":  model,
 				"method": "search_read",
 				"args":   []any{domain},
-				"kwargs": map[string]any{
-					"fields": fields,
-					"limit":  batchSize,
-					"offset": offset,
-				},
+				"kwargs": kwargs,
 			},
 			"id": 2,
 		}
 
-		resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+		resp, err := odooRequest(ctx, payload)
 		if err != nil {
-			return nil, err
+			return err
 		}
 
 		var rpcResp struct {
 			Result []map[string]any `json:"result"`
+			Error  any              `json:"error"`
 		}
 
 		if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
 			resp.Body.Close()
-			return nil, err
+			return err
 		}
 		resp.Body.Close()
+		if rpcResp.Error != nil {
+			return fmt.Errorf("odoo search_read on %s failed: %v", model, rpcResp.Error)
+		}
 
 		if len(rpcResp.Result) == 0 {
-			break
+			return nil
 		}
+		runStatsFrom(ctx).page()
 
-		results = append(results, rpcResp.Result...)
+		if err := fn(rpcResp.Result); err != nil {
+			return err
+		}
+		if len(rpcResp.Result) < batchSize {
+			return nil
+		}
 		offset += batchSize
 	}
-
-	return results, nil
-}
-
-// fetchMoves fetches account.move records by IDs
-func fetchMoves(moveIDs []int) (map[int]map[string]any, error) {
-	if len(moveIDs) == 0 {
-		return make(map[int]map[string]any), nil
-	}
-
-	moves, err := odooSearchRead(
-		"account.move",
-		[]any{[]any{"id", "in", moveIDs}},
-		[]string{"id", "name", "move_type", "journal_id"},
-		5000,
-	)
-	if err != nil {
-		return nil, err
-	}
-
-	moveMap := make(map[int]map[string]any)
-	for _, move := range moves {
-		id := getInt(move["id"])
-		moveMap[id] = move
-	}
-
-	return moveMap, nil
 }
 
 // Helper functions
@@ -288,32 +181,3 @@ func getString(v any) string {
 	}
 	return fmt.Sprintf("%v", v)
 }
-
-func getPartnerID(v any) int {
-	if list, ok := v.([]any); ok && len(list) > 0 {
-		return getInt(list[0])
-	}
-	return 0
-}
-
-func getPartnerName(v any) string {
-	if list, ok := v.([]any); ok && len(list) > 1 {
-		return getString(list[1])
-	}
-	return ""
-}
-
-func getMoveID(v any) int {
-	if list, ok := v.([]any); ok && len(list) > 0 {
-		return getInt(list[0])
-	}
-	return 0
-}
-
-func roundFloat(val float64, precision int) float64 {
-	multiplier := 1.0
-	for i := 0; i < precision; i++ {
-		multiplier *= 10
-	}
-	return float64(int(val*multiplier+0.5)) / multiplier
-}
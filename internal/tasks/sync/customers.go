@@ -8,7 +8,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
 	"github.com/hibiken/asynq"
@@ -16,21 +15,28 @@ import (
 )
 
 func HandleSyncCustomersTask(ctx context.Context, t *asynq.Task) error {
-	defer MarkCoreTaskCompletion(ctx)
 	log.Println("🔄 Starting customer sync...")
+	stats := runStatsFrom(ctx)
 
-	fields := []string{
-		"id", "x_studio_account_number", "display_name", "email",
-		"property_payment_term_id", "phone", "city", "hold_delivery_till_payment",
-		"credit_hold", "has_overdue_by_x_days", "total_overdue", "credit",
-		"zip", "days_sales_outstanding", "user_id", "property_product_pricelist",
-	}
+	since := syncMode(ctx, t, "res.partner", customerPages)
+	fullSync := since == ""
+	watermark := since
 
 	offset, limit, pageNum := 0, 1000, 1
 	allCustomers := []map[string]any{}
 
+	var build *generationBuild
+	if fullSync {
+		var err error
+		build, err = customerPages.beginGeneration(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to start customers generation: %v", err)
+			return err
+		}
+	}
+
 	for {
-		batch, err := fetchCustomers(ctx, offset, limit, fields)
+		batch, err := fetchCustomers(ctx, since, offset, limit, customerFields)
 		if err != nil {
 			log.Printf("❌ Failed to fetch customers batch: %v", err)
 			return err
@@ -39,51 +45,25 @@ func HandleSyncCustomersTask(ctx context.Context, t *asynq.Task) error {
 		if len(batch) == 0 {
 			break
 		}
+		stats.page()
+		stats.processed(len(batch))
 
 		pageDocs := []map[string]any{}
 		for _, c := range batch {
+			watermark = maxWriteDate(watermark, c)
 			customerDoc := cleanCustomer(c)
 
 			pageDocs = append(pageDocs, customerDoc)
 			allCustomers = append(allCustomers, customerDoc)
 
-			// Save each customer in Redis as hash
-			customerID := int(c["id"].(float64))
-			customerKey := fmt.Sprintf("customers:%d", customerID)
-
-			// Delete existing customer data
-			redisutil.RedisClient.Del(ctx, customerKey)
-
-			// Convert to map[string]string for HSET
-			sanitizedDoc := make(map[string]string)
-			for k, v := range customerDoc {
-				switch val := v.(type) {
-				case bool:
-					sanitizedDoc[k] = strconv.FormatBool(val)
-				case []string, []int, []float64:
-					// Convert lists to JSON string
-					jsonBytes, _ := json.Marshal(val)
-					sanitizedDoc[k] = string(jsonBytes)
-				default:
-					sanitizedDoc[k] = fmt.Sprintf("%v", val)
-				}
-			}
-
-			// Save as hash
-			if err := redisutil.RedisClient.HSet(ctx, customerKey, sanitizedDoc).Err(); err != nil {
-				log.Printf("⚠️  Failed to save customer %d to Redis: %v", customerID, err)
-			}
-
-			// Add to customers set
-			redisutil.RedisClient.SAdd(ctx, "customers", customerID)
+			saveCustomerHash(ctx, customerDoc)
 		}
 
-		// Cache page separately
-		pageJSON, err := json.Marshal(pageDocs)
-		if err != nil {
-			log.Printf("⚠️  Failed to marshal page: %v", err)
-		} else {
-			redisutil.RedisClient.Set(ctx, fmt.Sprintf("customers_page:%d", pageNum), pageJSON, 0)
+		// Cache page separately (incremental runs upsert in place below)
+		if fullSync {
+			if err := build.writePage(ctx, pageNum, pageDocs); err != nil {
+				log.Printf("⚠️  Failed to save customers page %d: %v", pageNum, err)
+			}
 		}
 
 		log.Printf("✅ Customers - Batch %d done", pageNum)
@@ -92,59 +72,133 @@ func HandleSyncCustomersTask(ctx context.Context, t *asynq.Task) error {
 		pageNum++
 	}
 
+	if fullSync {
+		if err := build.commit(ctx); err != nil {
+			log.Printf("❌ Failed to commit customers generation: %v", err)
+			return err
+		}
+	} else if err := customerPages.upsert(ctx, allCustomers); err != nil {
+		log.Printf("⚠️  Failed to upsert changed customer pages: %v", err)
+	}
+	if total, err := customerPages.size(ctx); err == nil {
+		stats.items(total)
+	}
+
 	// Ensure Typesense schema exists
 	if err := ensureCustomersSchema(ctx); err != nil {
 		log.Printf("❌ Failed to ensure schema: %v", err)
 		return err
 	}
 
-	// Wipe old docs and bulk import
+	// Full runs build a new version behind the alias, incremental runs upsert what changed
 	if len(allCustomers) > 0 {
-		// Delete old documents
-		filterBy := "customer_id:>0"
-		_, err := typesenseutil.TypesenseClient.Collection("customers").Documents().Delete(ctx, &api.DeleteDocumentsParams{
-			FilterBy: &filterBy,
-		})
-		if err != nil {
-			log.Printf("⚠️  Could not delete old documents (collection may not exist): %v", err)
-		}
-
 		// Convert to []any for Import
 		documents := make([]any, len(allCustomers))
 		for i, c := range allCustomers {
 			documents[i] = c
 		}
 
-		// Bulk import
-		action := api.IndexAction("upsert")
-		importResult, err := typesenseutil.TypesenseClient.Collection("customers").Documents().Import(ctx, documents, &api.ImportDocumentsParams{
-			Action: &action,
-		})
-		if err != nil {
-			log.Printf("❌ Failed to import customers to Typesense: %v", err)
-			return err
-		}
+		if fullSync {
+			collectionName, report, err := typesenseutil.ReindexWithAlias(ctx, "customers", currentRunID(ctx), customersSchema(), documents)
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to reindex customers in Typesense: %v", err)
+				return err
+			}
+
+			log.Printf("✅ Synced %d customers into Redis + Typesense (%s)", len(allCustomers), collectionName)
+		} else {
+			// Bulk import
+			report, err := typesenseutil.ImportDocuments(ctx, "customers", currentRunID(ctx), documents, api.IndexAction("upsert"))
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to import customers to Typesense: %v", err)
+				return err
+			}
 
-		log.Printf("✅ Synced %d customers into Redis + Typesense. Imported %d documents", len(allCustomers), len(importResult))
+			log.Printf("✅ Synced %d customers into Redis + Typesense. Imported %d documents, %d failed", len(allCustomers), report.Imported, report.Failed)
+		}
 	}
 
+	finishSync(ctx, "res.partner", watermark, fullSync)
 	log.Println("✅ Customer sync completed!")
 	return nil
 }
 
-// fetchCustomers fetches customers from Odoo in batches
-func fetchCustomers(ctx context.Context, offset, limit int, fields []string) ([]map[string]any, error) {
+var customerFields = []string{
+	"id", "x_studio_account_number", "display_name", "email",
+	"property_payment_term_id", "phone", "city", "hold_delivery_till_payment",
+	"credit_hold", "has_overdue_by_x_days", "total_overdue", "credit",
+	"zip", "days_sales_outstanding", "user_id", "property_product_pricelist",
+	"write_date",
+}
+
+// saveCustomerHash stores a cleaned customer as the customers:{id} hash and in the customers set
+func saveCustomerHash(ctx context.Context, customerDoc map[string]any) {
+	customerID := customerDoc["customer_id"]
+	customerKey := fmt.Sprintf("customers:%v", customerID)
+
+	// Delete existing customer data
+	redisutil.RedisClient.Del(ctx, customerKey)
+
+	// Convert to map[string]string for HSET
+	sanitizedDoc := make(map[string]string)
+	for k, v := range customerDoc {
+		switch val := v.(type) {
+		case bool:
+			sanitizedDoc[k] = strconv.FormatBool(val)
+		case []string, []int, []float64:
+			// Convert lists to JSON string
+			jsonBytes, _ := json.Marshal(val)
+			sanitizedDoc[k] = string(jsonBytes)
+		default:
+			sanitizedDoc[k] = fmt.Sprintf("%v", val)
+		}
+	}
+
+	// Save as hash
+	if err := redisutil.RedisClient.HSet(ctx, customerKey, sanitizedDoc).Err(); err != nil {
+		log.Printf("⚠️  Failed to save customer %v to Redis: %v", customerID, err)
+	}
+
+	// Add to customers set
+	redisutil.RedisClient.SAdd(ctx, "customers", customerID)
+}
+
+// removeCustomers drops customers from their hashes, pages and Typesense
+func removeCustomers(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	pipe := redisutil.RedisClient.Pipeline()
+	for _, id := range ids {
+		pipe.Del(ctx, fmt.Sprintf("customers:%s", id))
+		pipe.SRem(ctx, "customers", id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return err
+	}
+
+	if err := customerPages.remove(ctx, ids); err != nil {
+		return err
+	}
+	return deleteTypesenseDocuments(ctx, "customers", ids)
+}
+
+// fetchCustomers fetches customers from Odoo in batches, only those written since `since` when set
+func fetchCustomers(ctx context.Context, since string, offset, limit int, fields []string) ([]map[string]any, error) {
+	domain := writeDateDomain([]any{
+		[]any{"customer_rank", ">", 0},
+	}, since)
+
 	payload := map[string]any{
 		"jsonrpc": "2.0",
 		"method":  "call",
 		"params": map[string]any{
 			"model":  "res.partner",
 			"method": "search_read",
-			"args": []any{
-				[]any{
-					[]any{"customer_rank", ">", 0},
-				},
-			},
+			"args":   []any{domain},
 			"kwargs": map[string]any{
 				"fields": fields,
 				"offset": offset,
@@ -154,7 +208,7 @@ func fetchCustomers(ctx context.Context, offset, limit int, fields []string) ([]
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -262,20 +316,18 @@ func cleanCustomer(c map[string]any) map[string]any {
 	return cleaned
 }
 
-// ensureCustomersSchema ensures the Typesense customers collection schema exists
+// ensureCustomersSchema ensures the Typesense customers alias resolves to a collection
 func ensureCustomersSchema(ctx context.Context) error {
-	// Try to retrieve the collection
-	_, err := typesenseutil.TypesenseClient.Collection("customers").Retrieve(ctx)
-	if err == nil {
-		log.Println("✅ Customers collection exists")
-		return nil
+	if err := typesenseutil.EnsureAliasedCollection(ctx, "customers", customersSchema()); err != nil {
+		return fmt.Errorf("failed to create customers collection: %w", err)
 	}
+	return nil
+}
 
-	// Collection doesn't exist, create it
-	log.Println("⚠️  Customers collection missing, creating...")
-
+// customersSchema is the desired customers schema, applied on every full reindex
+func customersSchema() *api.CollectionSchema {
 	sortTrue := true
-	schema := &api.CollectionSchema{
+	return &api.CollectionSchema{
 		Name: "customers",
 		Fields: []api.Field{
 			{Name: "id", Type: "string"},
@@ -298,12 +350,4 @@ func ensureCustomersSchema(ctx context.Context) error {
 			{Name: "on_hold", Type: "bool", Facet: &sortTrue},
 		},
 	}
-
-	_, err = typesenseutil.TypesenseClient.Collections().Create(ctx, schema)
-	if err != nil {
-		return fmt.Errorf("failed to create customers collection: %w", err)
-	}
-
-	log.Println("✅ Customers collection created")
-	return nil
 }
@@ -0,0 +1,500 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/redis/go-redis/v9"
+)
+
+// buildTTL bounds how long keys of an unfinished generation live, so a crashed
+// sync cleans up after itself. Committing a generation removes the TTL.
+const buildTTL = 6 * time.Hour
+
+// pageRetries bounds how often a page rewrite restarts because another writer
+// changed the page between our read and our write
+const pageRetries = 5
+
+// retiredTTL is how long the previous generation stays readable after the flip,
+// for readers that resolved the pointer just before it moved
+const retiredTTL = 15 * time.Minute
+
+// pagedDataset is a generation-based JSON-page cache. A full sync writes
+// {prefix}:g{N}:{page} pages plus an id -> page index, verifies them and then
+// flips {prefix}:current. Incremental runs patch pages of the current generation.
+type pagedDataset struct {
+	prefix string
+	// legacyKeys are the index and count keys of the old {prefix}:{page} layout
+	legacyKeys []string
+}
+
+var (
+	productPages  = pagedDataset{prefix: "products", legacyKeys: []string{"products:page_index", "products:page_count"}}
+	customerPages = pagedDataset{prefix: "customers_page", legacyKeys: []string{"customers_page:index", "customers_page:count"}}
+	orderPages    = pagedDataset{prefix: "orders_page", legacyKeys: []string{"orders_page:index", "orders_page:count"}}
+)
+
+func (dataset pagedDataset) pageKey(gen int64, page int) string {
+	return redisutil.GenerationKey(dataset.prefix, gen, strconv.Itoa(page))
+}
+
+func (dataset pagedDataset) indexKey(gen int64) string {
+	return redisutil.GenerationKey(dataset.prefix, gen, "index")
+}
+
+func (dataset pagedDataset) countKey(gen int64) string {
+	return redisutil.GenerationKey(dataset.prefix, gen, "page_count")
+}
+
+// hasGeneration reports whether a generation was ever committed
+func (dataset pagedDataset) hasGeneration(ctx context.Context) bool {
+	_, err := redisutil.CurrentGeneration(ctx, dataset.prefix)
+	return err == nil
+}
+
+// generationBuild tracks a generation while a full sync writes it
+type generationBuild struct {
+	dataset pagedDataset
+	gen     int64
+	pages   int
+	ids     map[string]bool
+}
+
+// beginGeneration reserves the next generation number for a full rebuild
+func (dataset pagedDataset) beginGeneration(ctx context.Context) (*generationBuild, error) {
+	gen, err := redisutil.RedisClient.Incr(ctx, dataset.prefix+":generation_seq").Result()
+	if err != nil {
+		return nil, err
+	}
+	return &generationBuild{dataset: dataset, gen: gen, ids: make(map[string]bool)}, nil
+}
+
+// writePage writes a page of the generation being built
+func (build *generationBuild) writePage(ctx context.Context, page int, records []map[string]any) error {
+	// Count the page even if the write fails, so commit notices the gap
+	build.pages = max(build.pages, page)
+	if err := build.dataset.storePage(ctx, build.gen, page, records, buildTTL); err != nil {
+		return err
+	}
+	for _, record := range records {
+		build.ids[getString(record["id"])] = true
+	}
+	return nil
+}
+
+// storePage stores a whole page and indexes its records
+func (dataset pagedDataset) storePage(ctx context.Context, gen int64, page int, records []map[string]any, ttl time.Duration) error {
+	pageJSON, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	return redisutil.FencedTx(ctx, func(pipe redis.Pipeliner) error {
+		dataset.queuePage(ctx, pipe, gen, page, pageJSON, records, ttl)
+		return nil
+	})
+}
+
+func (dataset pagedDataset) queuePage(ctx context.Context, pipe redis.Pipeliner, gen int64, page int, pageJSON []byte, records []map[string]any, ttl time.Duration) {
+	pipe.Set(ctx, dataset.pageKey(gen, page), pageJSON, ttl)
+	if len(records) > 0 {
+		index := make(map[string]any, len(records))
+		for _, record := range records {
+			index[getString(record["id"])] = page
+		}
+		pipe.HSet(ctx, dataset.indexKey(gen), index)
+		if ttl > 0 {
+			pipe.Expire(ctx, dataset.indexKey(gen), ttl)
+		}
+	}
+}
+
+// rewritePage is a read-modify-write of one page of a committed generation.
+// The page is WATCHed, so when an incremental sync, a record refresh or the
+// stock sync rewrites it concurrently, the loser re-reads and reapplies its
+// edit instead of overwriting the other's records. edit changes the records
+// and reports whether the page needs writing.
+func (dataset pagedDataset) rewritePage(ctx context.Context, gen int64, page int, edit func(records []map[string]any) ([]map[string]any, bool)) error {
+	key := dataset.pageKey(gen, page)
+	for attempt := 0; attempt < pageRetries; attempt++ {
+		err := redisutil.FencedWatch(ctx, func(tx *redis.Tx) error {
+			existing, err := dataset.readPageFrom(ctx, tx, gen, page)
+			if err != nil {
+				return err
+			}
+			records, dirty := edit(existing)
+			if !dirty {
+				return nil
+			}
+			pageJSON, err := json.Marshal(records)
+			if err != nil {
+				return err
+			}
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				dataset.queuePage(ctx, pipe, gen, page, pageJSON, records, 0)
+				return nil
+			})
+			return err
+		}, key)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s: gave up after %d concurrent rewrites", key, pageRetries)
+}
+
+// commit verifies the generation holds every page and record, then atomically
+// makes it current and lets the previous one expire
+func (build *generationBuild) commit(ctx context.Context) error {
+	dataset, gen, pages := build.dataset, build.gen, build.pages
+
+	keys := make([]string, 0, pages+2)
+	for page := 1; page <= pages; page++ {
+		keys = append(keys, dataset.pageKey(gen, page))
+	}
+
+	if pages > 0 {
+		found, err := redisutil.RedisClient.Exists(ctx, keys...).Result()
+		if err != nil {
+			return err
+		}
+		if found != int64(pages) {
+			return fmt.Errorf("%s generation %d: %d of %d pages written", dataset.prefix, gen, found, pages)
+		}
+	}
+
+	indexed, err := redisutil.RedisClient.HLen(ctx, dataset.indexKey(gen)).Result()
+	if err != nil {
+		return err
+	}
+	if indexed != int64(len(build.ids)) {
+		return fmt.Errorf("%s generation %d: %d of %d records indexed", dataset.prefix, gen, indexed, len(build.ids))
+	}
+
+	previous, prevErr := redisutil.CurrentGeneration(ctx, dataset.prefix)
+	previousPages := 0
+	if prevErr == nil {
+		previousPages, _ = redisutil.RedisClient.Get(ctx, dataset.countKey(previous)).Int()
+	}
+
+	// Fenced, so a run that lost the sync lock can't flip the pointer over a newer run
+	keys = append(keys, dataset.indexKey(gen))
+	err = redisutil.FencedTx(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, dataset.countKey(gen), pages, 0)
+		for _, key := range keys {
+			pipe.Persist(ctx, key)
+		}
+		pipe.Set(ctx, redisutil.GenerationPointerKey(dataset.prefix), gen, 0)
+		if prevErr == nil && previous != gen {
+			for page := 1; page <= previousPages; page++ {
+				pipe.Expire(ctx, dataset.pageKey(previous, page), retiredTTL)
+			}
+			pipe.Expire(ctx, dataset.indexKey(previous), retiredTTL)
+			pipe.Expire(ctx, dataset.countKey(previous), retiredTTL)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := dataset.dropLegacyPages(ctx); err != nil {
+		log.Printf("⚠️  Failed to remove legacy %s pages: %v", dataset.prefix, err)
+	}
+	return nil
+}
+
+// dropLegacyPages deletes the keys of the pre-generation layout ({prefix}:{page}
+// plus its index and count keys) once a generation is live. It runs until it
+// succeeds once per dataset.
+func (dataset pagedDataset) dropLegacyPages(ctx context.Context) error {
+	doneKey := dataset.prefix + ":legacy_removed"
+	if done, _ := redisutil.RedisClient.Exists(ctx, doneKey).Result(); done > 0 {
+		return nil
+	}
+
+	legacy := []string{}
+	iter := redisutil.RedisClient.Scan(ctx, 0, dataset.prefix+":[0-9]*", 500).Iterator()
+	for iter.Next(ctx) {
+		// products:total and friends share the prefix; only bare page numbers are legacy
+		if _, err := strconv.Atoi(strings.TrimPrefix(iter.Val(), dataset.prefix+":")); err == nil {
+			legacy = append(legacy, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	legacy = append(legacy, dataset.legacyKeys...)
+
+	for start := 0; start < len(legacy); start += 500 {
+		end := min(start+500, len(legacy))
+		if err := redisutil.RedisClient.Del(ctx, legacy[start:end]...).Err(); err != nil {
+			return err
+		}
+	}
+	log.Printf("🧹 Removed %d legacy %s keys", len(legacy), dataset.prefix)
+	return redisutil.RedisClient.Set(ctx, doneKey, time.Now().Unix(), 0).Err()
+}
+
+// records returns the number of distinct records written so far
+func (build *generationBuild) records() int {
+	return len(build.ids)
+}
+
+// current returns the live generation, or an error asking for a full sync
+func (dataset pagedDataset) current(ctx context.Context) (int64, error) {
+	gen, err := redisutil.CurrentGeneration(ctx, dataset.prefix)
+	if errors.Is(err, redis.Nil) {
+		return 0, fmt.Errorf("%s has no committed generation, a full sync is required", dataset.prefix)
+	}
+	return gen, err
+}
+
+// upsert replaces records in the page they already live in and appends new ones
+// to the last page of the current generation
+func (dataset pagedDataset) upsert(ctx context.Context, records []map[string]any) error {
+	if len(records) == 0 {
+		return nil
+	}
+
+	gen, err := dataset.current(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, len(records))
+	for i, record := range records {
+		ids[i] = getString(record["id"])
+	}
+	pagesForIDs, err := redisutil.RedisClient.HMGet(ctx, dataset.indexKey(gen), ids...).Result()
+	if err != nil {
+		return err
+	}
+
+	lastPage, _ := redisutil.RedisClient.Get(ctx, dataset.countKey(gen)).Int()
+	if lastPage == 0 {
+		lastPage = 1
+	}
+
+	byPage := make(map[int][]map[string]any)
+	for i, record := range records {
+		page := lastPage
+		if raw, ok := pagesForIDs[i].(string); ok {
+			if p, err := strconv.Atoi(raw); err == nil {
+				page = p
+			}
+		}
+		byPage[page] = append(byPage[page], record)
+	}
+
+	for page, updates := range byPage {
+		err := dataset.rewritePage(ctx, gen, page, func(existing []map[string]any) ([]map[string]any, bool) {
+			positions := make(map[string]int, len(existing))
+			for i, record := range existing {
+				positions[getString(record["id"])] = i
+			}
+			for _, record := range updates {
+				if i, ok := positions[getString(record["id"])]; ok {
+					existing[i] = record
+				} else {
+					existing = append(existing, record)
+				}
+			}
+			return existing, true
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return redisutil.RedisClient.SetNX(ctx, dataset.countKey(gen), lastPage, 0).Err()
+}
+
+// patch merges fields into records of the current generation that already exist,
+// leaving unknown ids to the next full or incremental run. It returns the ids
+// whose stored values actually changed.
+func (dataset pagedDataset) patch(ctx context.Context, updates map[string]map[string]any) ([]string, error) {
+	return dataset.mergeUpdates(ctx, updates, true)
+}
+
+// changes returns the ids patch would change, without writing anything
+func (dataset pagedDataset) changes(ctx context.Context, updates map[string]map[string]any) ([]string, error) {
+	return dataset.mergeUpdates(ctx, updates, false)
+}
+
+func (dataset pagedDataset) mergeUpdates(ctx context.Context, updates map[string]map[string]any, write bool) ([]string, error) {
+	if len(updates) == 0 {
+		return nil, nil
+	}
+
+	gen, err := dataset.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id)
+	}
+	pagesForIDs, err := redisutil.RedisClient.HMGet(ctx, dataset.indexKey(gen), ids...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	byPage := make(map[int]bool)
+	for _, raw := range pagesForIDs {
+		if s, ok := raw.(string); ok {
+			if page, err := strconv.Atoi(s); err == nil {
+				byPage[page] = true
+			}
+		}
+	}
+
+	changed := []string{}
+	for page := range byPage {
+		if !write {
+			existing, err := dataset.readPage(ctx, gen, page)
+			if err != nil {
+				return nil, err
+			}
+			changed = append(changed, mergeFields(existing, updates)...)
+			continue
+		}
+
+		var pageChanged []string
+		err := dataset.rewritePage(ctx, gen, page, func(existing []map[string]any) ([]map[string]any, bool) {
+			pageChanged = mergeFields(existing, updates)
+			return existing, len(pageChanged) > 0
+		})
+		if err != nil {
+			return nil, err
+		}
+		changed = append(changed, pageChanged...)
+	}
+	return changed, nil
+}
+
+// mergeFields merges updates into the matching records of a page and returns
+// the ids whose values changed
+func mergeFields(records []map[string]any, updates map[string]map[string]any) []string {
+	changed := []string{}
+	for _, record := range records {
+		id := getString(record["id"])
+		fields, ok := updates[id]
+		if !ok {
+			continue
+		}
+		recordChanged := false
+		for field, value := range fields {
+			// Pages round-trip through JSON, so compare the JSON forms
+			if !sameJSON(record[field], value) {
+				record[field] = value
+				recordChanged = true
+			}
+		}
+		if recordChanged {
+			changed = append(changed, id)
+		}
+	}
+	return changed
+}
+
+func sameJSON(a, b any) bool {
+	aJSON, _ := json.Marshal(a)
+	bJSON, _ := json.Marshal(b)
+	return string(aJSON) == string(bJSON)
+}
+
+// remove drops records from their pages and from the index of the current generation
+func (dataset pagedDataset) remove(ctx context.Context, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	gen, err := dataset.current(ctx)
+	if err != nil {
+		return err
+	}
+
+	pagesForIDs, err := redisutil.RedisClient.HMGet(ctx, dataset.indexKey(gen), ids...).Result()
+	if err != nil {
+		return err
+	}
+
+	byPage := make(map[int]map[string]bool)
+	for i, id := range ids {
+		raw, ok := pagesForIDs[i].(string)
+		if !ok {
+			continue
+		}
+		page, err := strconv.Atoi(raw)
+		if err != nil {
+			continue
+		}
+		if byPage[page] == nil {
+			byPage[page] = make(map[string]bool)
+		}
+		byPage[page][id] = true
+	}
+
+	for page, removed := range byPage {
+		err := dataset.rewritePage(ctx, gen, page, func(existing []map[string]any) ([]map[string]any, bool) {
+			kept := make([]map[string]any, 0, len(existing))
+			for _, record := range existing {
+				if !removed[getString(record["id"])] {
+					kept = append(kept, record)
+				}
+			}
+			return kept, len(kept) != len(existing)
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return redisutil.RedisClient.HDel(ctx, dataset.indexKey(gen), ids...).Err()
+}
+
+// ids returns every record id in the current generation
+func (dataset pagedDataset) ids(ctx context.Context) ([]string, error) {
+	gen, err := dataset.current(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return redisutil.RedisClient.HKeys(ctx, dataset.indexKey(gen)).Result()
+}
+
+// size returns the number of records in the current generation
+func (dataset pagedDataset) size(ctx context.Context) (int64, error) {
+	gen, err := dataset.current(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return redisutil.RedisClient.HLen(ctx, dataset.indexKey(gen)).Result()
+}
+
+func (dataset pagedDataset) readPage(ctx context.Context, gen int64, page int) ([]map[string]any, error) {
+	return dataset.readPageFrom(ctx, redisutil.RedisClient, gen, page)
+}
+
+func (dataset pagedDataset) readPageFrom(ctx context.Context, client redis.Cmdable, gen int64, page int) ([]map[string]any, error) {
+	existing := []map[string]any{}
+	pageJSON, err := client.Get(ctx, dataset.pageKey(gen, page)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return existing, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(pageJSON, &existing); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", dataset.pageKey(gen, page), err)
+	}
+	return existing, nil
+}
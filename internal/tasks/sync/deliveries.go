@@ -0,0 +1,298 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/hibiken/asynq"
+)
+
+const (
+	// deliveryOrdersKey is the set of orders with a deliveries list
+	deliveryOrdersKey = "deliveries:orders"
+	// deliveryRepsKey is the set of reps with a due-today list
+	deliveryRepsKey = "deliveries:reps"
+)
+
+// deliveriesKey holds an order's outgoing pickings as a JSON array
+func deliveriesKey(orderID any) string {
+	return fmt.Sprintf("deliveries:order:%v", orderID)
+}
+
+// dueDeliveriesKey holds a rep's deliveries scheduled for one day
+func dueDeliveriesKey(userID any) string {
+	return fmt.Sprintf("deliveries:due:%v", userID)
+}
+
+var pickingFields = []string{
+	"id", "name", "origin", "sale_id", "partner_id", "state",
+	"scheduled_date", "date_deadline", "date_done",
+	"carrier_id", "carrier_tracking_ref", "backorder_id",
+}
+
+var pickingMoveFields = []string{
+	"id", "picking_id", "product_id", "product_uom_qty", "quantity", "state",
+}
+
+// HandleSyncDeliveriesTask fetches the outgoing pickings of synced sale orders
+// with their moves, stores them per order and builds each rep's list of
+// deliveries due today
+func HandleSyncDeliveriesTask(ctx context.Context, t *asynq.Task) error {
+	log.Println("🔄 Starting deliveries sync...")
+	stats := runStatsFrom(ctx)
+	startTime := time.Now()
+
+	// Same window as the order sync, so every picking belongs to a synced order
+	sixMonthsAgo := time.Now().UTC().AddDate(0, 0, -180)
+	domain := []any{
+		[]any{"picking_type_code", "=", "outgoing"},
+		[]any{"sale_id", "!=", false},
+		[]any{"sale_id.date_order", ">", sixMonthsAgo.Format(odooDatetimeLayout)},
+	}
+
+	today := time.Now().Format("2006-01-02")
+	byOrder := make(map[int][]map[string]any)
+	dueByRep := make(map[int][]map[string]any)
+	total := 0
+	err := odooSearchReadPages(ctx, "stock.picking", domain, pickingFields, "id", 1000, func(page []map[string]any) error {
+		stats.page()
+
+		moves, err := fetchPickingMoves(ctx, page)
+		if err != nil {
+			return err
+		}
+		reps, err := fetchOrderReps(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		for _, picking := range page {
+			delivery := cleanPicking(picking, moves[getInt(picking["id"])])
+			orderID := delivery["order_id"].(int)
+			byOrder[orderID] = append(byOrder[orderID], delivery)
+			total++
+
+			if delivery["scheduled_day"] == today && delivery["state"] != "done" && delivery["state"] != "cancel" {
+				if userID := reps[orderID]; userID != 0 {
+					dueByRep[userID] = append(dueByRep[userID], delivery)
+				}
+			}
+		}
+		stats.processed(len(page))
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Failed to fetch deliveries: %v", err)
+		return err
+	}
+
+	if err := saveOrderDeliveries(ctx, byOrder); err != nil {
+		log.Printf("❌ Redis couldn't save order deliveries: %v", err)
+		return err
+	}
+	if err := saveDueDeliveries(ctx, today, dueByRep); err != nil {
+		log.Printf("❌ Redis couldn't save due deliveries: %v", err)
+		return err
+	}
+
+	stats.items(int64(total))
+	log.Printf("✅ Deliveries sync complete — %d pickings for %d orders, %d reps with deliveries today in %.2fs",
+		total, len(byOrder), len(dueByRep), time.Since(startTime).Seconds())
+	return nil
+}
+
+// fetchPickingMoves returns the stock moves of a page of pickings, by picking id
+func fetchPickingMoves(ctx context.Context, pickings []map[string]any) (map[int][]map[string]any, error) {
+	ids := make([]int, len(pickings))
+	for i, picking := range pickings {
+		ids[i] = getInt(picking["id"])
+	}
+
+	moves, err := odooSearchRead(ctx, "stock.move", []any{[]any{"picking_id", "in", ids}}, pickingMoveFields, 2000)
+	if err != nil {
+		return nil, err
+	}
+	byPicking := make(map[int][]map[string]any, len(pickings))
+	for _, move := range moves {
+		pickingID := 0
+		if picking, ok := move["picking_id"].([]any); ok && len(picking) > 0 {
+			pickingID = getInt(picking[0])
+		}
+		productID := 0
+		if product, ok := move["product_id"].([]any); ok && len(product) > 0 {
+			productID = getInt(product[0])
+		}
+		byPicking[pickingID] = append(byPicking[pickingID], map[string]any{
+			"id":         getInt(move["id"]),
+			"product_id": productID,
+			"product":    many2oneName(move["product_id"]),
+			"ordered":    getFloat(move["product_uom_qty"]),
+			"done":       getFloat(move["quantity"]),
+			"state":      getStringOrNA(move["state"]),
+		})
+	}
+	for _, pickingMoves := range byPicking {
+		sort.Slice(pickingMoves, func(a, b int) bool {
+			return pickingMoves[a]["id"].(int) < pickingMoves[b]["id"].(int)
+		})
+	}
+	return byPicking, nil
+}
+
+// fetchOrderReps returns the salesperson (res.users id) of each order in a page of pickings
+func fetchOrderReps(ctx context.Context, pickings []map[string]any) (map[int]int, error) {
+	ids := []int{}
+	seen := make(map[int]bool)
+	for _, picking := range pickings {
+		if sale, ok := picking["sale_id"].([]any); ok && len(sale) > 0 {
+			if id := getInt(sale[0]); !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	reps := make(map[int]int, len(ids))
+	if len(ids) == 0 {
+		return reps, nil
+	}
+	orders, err := odooSearchRead(ctx, "sale.order", []any{[]any{"id", "in", ids}}, []string{"id", "user_id"}, 2000)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if user, ok := order["user_id"].([]any); ok && len(user) > 0 {
+			reps[getInt(order["id"])] = getInt(user[0])
+		}
+	}
+	return reps, nil
+}
+
+// cleanPicking turns an Odoo picking and its moves into the delivery document.
+// A picking with backorder_of set carries goods left over from an earlier delivery.
+func cleanPicking(picking map[string]any, moves []map[string]any) map[string]any {
+	orderID, orderName := 0, ""
+	if sale, ok := picking["sale_id"].([]any); ok && len(sale) >= 2 {
+		orderID = getInt(sale[0])
+		orderName = getString(sale[1])
+	}
+	if moves == nil {
+		moves = []map[string]any{}
+	}
+
+	// Odoo datetimes are UTC; the due-today list uses the worker's local day (TZ)
+	scheduled := odooDatetime(picking["scheduled_date"])
+	scheduledDay := ""
+	if !scheduled.IsZero() {
+		scheduledDay = scheduled.Local().Format("2006-01-02")
+	}
+
+	// Odoo sends false for an empty tracking reference
+	trackingRef, _ := picking["carrier_tracking_ref"].(string)
+
+	return map[string]any{
+		"id":             getInt(picking["id"]),
+		"name":           getStringOrNA(picking["name"]),
+		"order_id":       orderID,
+		"order_name":     orderName,
+		"partner_name":   many2oneName(picking["partner_id"]),
+		"state":          getStringOrNA(picking["state"]),
+		"scheduled_date": odooDateString(picking["scheduled_date"]),
+		"scheduled_day":  scheduledDay,
+		"date_deadline":  odooDateString(picking["date_deadline"]),
+		"date_done":      odooDateString(picking["date_done"]),
+		"carrier":        many2oneName(picking["carrier_id"]),
+		"tracking_ref":   trackingRef,
+		"backorder_of":   many2oneName(picking["backorder_id"]),
+		"moves":          moves,
+	}
+}
+
+// saveOrderDeliveries rewrites the deliveries of every order seen in this run,
+// earliest scheduled first, and drops the lists of orders no longer returned
+func saveOrderDeliveries(ctx context.Context, byOrder map[int][]map[string]any) error {
+	// Refuse to wipe every list if Odoo returned nothing
+	if len(byOrder) == 0 {
+		return nil
+	}
+	previous, err := redisutil.RedisClient.SMembers(ctx, deliveryOrdersKey).Result()
+	if err != nil {
+		return err
+	}
+
+	orders := make([]any, 0, len(byOrder))
+	pipe := redisutil.RedisClient.TxPipeline()
+	for orderID, deliveries := range byOrder {
+		sort.SliceStable(deliveries, func(a, b int) bool {
+			return deliveries[a]["scheduled_date"].(string) < deliveries[b]["scheduled_date"].(string)
+		})
+		data, _ := json.Marshal(deliveries)
+		pipe.Set(ctx, deliveriesKey(orderID), data, 0)
+		orders = append(orders, strconv.Itoa(orderID))
+	}
+	for _, orderID := range previous {
+		if id, err := strconv.Atoi(orderID); err != nil || byOrder[id] == nil {
+			pipe.Del(ctx, deliveriesKey(orderID))
+		}
+	}
+	pipe.Del(ctx, deliveryOrdersKey)
+	pipe.SAdd(ctx, deliveryOrdersKey, orders...)
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// saveDueDeliveries rewrites each rep's deliveries due on day, and clears the
+// lists of reps with nothing due
+func saveDueDeliveries(ctx context.Context, day string, dueByRep map[int][]map[string]any) error {
+	previous, err := redisutil.RedisClient.SMembers(ctx, deliveryRepsKey).Result()
+	if err != nil {
+		return err
+	}
+
+	reps := make([]any, 0, len(dueByRep))
+	pipe := redisutil.RedisClient.TxPipeline()
+	for userID, deliveries := range dueByRep {
+		sort.SliceStable(deliveries, func(a, b int) bool {
+			return deliveries[a]["scheduled_date"].(string) < deliveries[b]["scheduled_date"].(string)
+		})
+		data, _ := json.Marshal(map[string]any{"date": day, "deliveries": deliveries})
+		pipe.Set(ctx, dueDeliveriesKey(userID), data, 48*time.Hour)
+		reps = append(reps, strconv.Itoa(userID))
+	}
+	for _, userID := range previous {
+		if id, err := strconv.Atoi(userID); err != nil || dueByRep[id] == nil {
+			pipe.Del(ctx, dueDeliveriesKey(userID))
+		}
+	}
+	pipe.Del(ctx, deliveryRepsKey)
+	if len(reps) > 0 {
+		pipe.SAdd(ctx, deliveryRepsKey, reps...)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// odooDatetime parses an Odoo UTC datetime, or returns the zero time for false
+func odooDatetime(v any) time.Time {
+	value, _ := v.(string)
+	parsed, err := time.Parse(odooDatetimeLayout, value)
+	if err != nil {
+		return time.Time{}
+	}
+	return parsed
+}
+
+// odooDateString returns an Odoo datetime as RFC3339 UTC, or "" for false
+func odooDateString(v any) string {
+	parsed := odooDatetime(v)
+	if parsed.IsZero() {
+		return ""
+	}
+	return parsed.UTC().Format(time.RFC3339)
+}
@@ -3,29 +3,42 @@ package sync
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
 	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
 	"github.com/typesense/typesense-go/v4/typesense/api"
 )
 
 func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
-	log.Println("🔄 Starting unified invoice + invoice lines sync...")
-
-	// Load last sync timestamp
+	log.Println("🔄 Starting unified invoice, credit note + lines sync...")
+	stats := runStatsFrom(ctx)
+
+	// Load last sync timestamp; a full run ignores it and re-reads the whole window
+	fullSync := fullSyncRequested(t, "account.move")
+	// Invoices stored under a different set of move types were never fetched
+	// for the new ones, and their watermarks would skip them: re-read once
+	moveTypes := strings.Join(invoiceMoveTypes, ",")
+	if stored, _ := redisutil.RedisClient.Get(ctx, invoiceMoveTypesKey).Result(); stored != moveTypes {
+		log.Printf("🔁 Invoice move types changed (%q -> %q), backfilling the whole window", stored, moveTypes)
+		fullSync = true
+	}
 	lastSyncKey := "invoices:last_sync_datetime"
-	lastSyncStr, _ := redisutil.RedisClient.Get(ctx, lastSyncKey).Result()
+	lastSyncStr := ""
+	if !fullSync {
+		lastSyncStr, _ = redisutil.RedisClient.Get(ctx, lastSyncKey).Result()
+	}
 
 	var lastSync time.Time
 	if lastSyncStr != "" {
-		existingIDsRaw, _ := redisutil.RedisClient.Get(ctx, "invoices:all_ids").Result()
+		existingIDsRaw, _ := redisutil.RedisClient.Get(ctx, invoiceIDsKey).Result()
 		if existingIDsRaw != "" {
 			var existingIDs []int
 			if err := json.Unmarshal([]byte(existingIDsRaw), &existingIDs); err == nil && len(existingIDs) != 0 {
@@ -53,24 +66,40 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 		log.Println("🆕 First-time invoice sync → fetching last 180 days")
 	}
 
-	fields := []string{
-		"id", "name", "invoice_date", "partner_id",
-		"amount_total", "payment_state",
-		"x_studio_related_field_6nn_1ihffsbf0",
-	}
-
 	allInvoiceIDs := []int{}
 	allInvoices := []map[string]any{}
+	removedInvoiceIDs := []int{}
 	offset, limit, pageNum := 0, 2000, 1
 	maxInvoiceDate := lastSync
 
 	// Fetch invoices
 	domain := []any{
-		[]any{"move_type", "=", "out_invoice"},
+		invoiceTypeDomain(),
 		[]any{"state", "=", "posted"},
 		[]any{"invoice_date", ">", lastSync.Format("2006-01-02")},
 	}
 
+	// Besides new invoice dates, pick up anything written since the last run
+	// (payments, reversals, cancellations) so payment_state and amounts stay current
+	writeWatermark := ""
+	if !fullSync {
+		writeWatermark, _ = redisutil.RedisClient.Get(ctx, watermarkKey("account.move")).Result()
+	}
+	maxWriteWatermark := writeWatermark
+	if writeWatermark != "" {
+		windowStart := time.Now().UTC().AddDate(0, 0, -180)
+		domain = []any{
+			invoiceTypeDomain(),
+			[]any{"invoice_date", ">", windowStart.Format("2006-01-02")},
+			"|",
+			"&",
+			[]any{"state", "=", "posted"},
+			[]any{"invoice_date", ">", lastSync.Format("2006-01-02")},
+			[]any{"write_date", ">=", writeWatermark},
+		}
+		log.Printf("⏩ Also refreshing invoices written since %s", writeWatermark)
+	}
+
 	for {
 		payload := map[string]any{
 			"jsonrpc": "2.0",
@@ -80,7 +109,7 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 				"method": "search_read",
 				"args":   []any{domain},
 				"kwargs": map[string]any{
-					"fields": fields,
+					"fields": invoiceFields,
 					"offset": offset,
 					"limit":  limit,
 				},
@@ -88,7 +117,7 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 			"id": 2,
 		}
 
-		resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+		resp, err := odooRequest(ctx, payload)
 		if err != nil {
 			log.Printf("❌ Failed to fetch invoices batch: %v", err)
 			return err
@@ -109,6 +138,7 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 		if len(invoices) == 0 {
 			break
 		}
+		stats.page()
 
 		pageDocs := []map[string]any{}
 
@@ -117,66 +147,17 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 			if id, ok := inv["id"].(float64); ok {
 				invoiceID = int(id)
 			}
+			maxWriteWatermark = maxWriteDate(maxWriteWatermark, inv)
 
-			// Extract fields
-			invDate := ""
-			if inv["invoice_date"] != nil {
-				invDate = fmt.Sprintf("%v", inv["invoice_date"])
-			}
-			dtInv, _ := time.Parse("2006-01-02T15:04:05", strings.Replace(invDate, " ", "T", 1))
-			tsInv := int(dtInv.Unix())
-
-			partnerName := "NA"
-			partnerID := 0
-			if partner, ok := inv["partner_id"].([]any); ok && len(partner) >= 2 {
-				if id, ok := partner[0].(float64); ok {
-					partnerID = int(id)
-				}
-				partnerName = fmt.Sprintf("%v", partner[1])
-			}
-
-			salesperson := "Unknown"
-			if sp, ok := inv["x_studio_related_field_6nn_1ihffsbf0"].([]any); ok && len(sp) > 0 {
-				salesperson = fmt.Sprintf("%v", sp[0])
-			} else if sp := inv["x_studio_related_field_6nn_1ihffsbf0"]; sp != nil {
-				salesperson = fmt.Sprintf("%v", sp)
-			}
-
-			name := "NA"
-			if inv["name"] != nil {
-				name = fmt.Sprintf("%v", inv["name"])
-			}
-
-			amountTotal := 0.0
-			if amt, ok := inv["amount_total"].(float64); ok {
-				amountTotal = amt
-			}
-
-			paymentState := "NA"
-			if inv["payment_state"] != nil {
-				paymentState = fmt.Sprintf("%v", inv["payment_state"])
-			}
-
-			invoiceDoc := map[string]any{
-				"id":              fmt.Sprintf("%d", invoiceID),
-				"name":            name,
-				"invoice_date":    invDate,
-				"invoice_date_ts": tsInv,
-				"partner_id":      partnerID,
-				"partner_name":    partnerName,
-				"salesperson":     salesperson,
-				"amount_total":    amountTotal,
-				"payment_state":   paymentState,
-				"pdf_url":         fmt.Sprintf("%s/report/pdf/account.report_invoice/%d", config.ConfigGlobal.OdooURL, invoiceID),
+			// Cancelled or reset to draft since we stored it
+			if state, _ := inv["state"].(string); state != "posted" {
+				removedInvoiceIDs = append(removedInvoiceIDs, invoiceID)
+				stats.skipped(1)
+				continue
 			}
 
-			// Save invoice header
-			invoiceKey := fmt.Sprintf("invoices:%d", invoiceID)
-			hsetMap := make(map[string]string)
-			for k, v := range invoiceDoc {
-				hsetMap[k] = fmt.Sprintf("%v", v)
-			}
-			redisutil.RedisClient.HSet(ctx, invoiceKey, hsetMap)
+			invoiceDoc, dtInv := cleanInvoice(inv)
+			saveInvoiceHeader(ctx, invoiceDoc)
 
 			allInvoiceIDs = append(allInvoiceIDs, invoiceID)
 			allInvoices = append(allInvoices, invoiceDoc)
@@ -188,50 +169,240 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 			}
 		}
 
+		stats.processed(len(pageDocs))
 		log.Printf("[INVOICE_SYNC][PAGE] Invoices page %d synced | Records: %d", pageNum, len(pageDocs))
 
 		offset += limit
 		pageNum++
 	}
 
-	// Merge with existing invoice IDs
-	existingIDsRaw, _ := redisutil.RedisClient.Get(ctx, "invoices:all_ids").Result()
-	existingIDs := make(map[int]bool)
-	if existingIDsRaw != "" {
-		var ids []int
-		if err := json.Unmarshal([]byte(existingIDsRaw), &ids); err == nil {
-			for _, id := range ids {
-				existingIDs[id] = true
+	removeInvoices(ctx, removedInvoiceIDs)
+	total, err := mergeInvoiceIDs(ctx, allInvoiceIDs, removedInvoiceIDs)
+	if err != nil {
+		log.Printf("❌ Failed to save invoice ids: %v", err)
+	}
+	stats.items(int64(total))
+	log.Printf("✅Total invoices stored: %d", total)
+
+	// Fetch invoice lines
+	log.Printf("🔄 Fetching invoice lines for %d invoices...", len(allInvoiceIDs))
+	syncInvoiceLines(ctx, allInvoiceIDs)
+
+	// Typesense
+	if err := ensureInvoicesSchema(ctx); err != nil {
+		log.Printf("❌ Failed to ensure schema: %v", err)
+		return err
+	}
+
+	if len(allInvoices) > 0 {
+		documents := make([]any, len(allInvoices))
+		for i, inv := range allInvoices {
+			documents[i] = inv
+		}
+
+		// Full runs build a new version behind the alias, incremental runs upsert what changed
+		if fullSync {
+			collectionName, report, err := typesenseutil.ReindexWithAlias(ctx, "invoices", currentRunID(ctx), invoicesSchema(), documents)
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to reindex invoices in Typesense: %v", err)
+				return err
+			}
+			log.Printf("✅ Reindexed %d invoices into %s", report.Imported, collectionName)
+		} else {
+			report, err := typesenseutil.ImportDocuments(ctx, "invoices", currentRunID(ctx), documents, api.IndexAction("upsert"))
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to import invoices to Typesense: %v", err)
+			} else {
+				log.Printf("✅ Imported %d invoices into Typesense, %d failed", report.Imported, report.Failed)
 			}
 		}
+	} else {
+		log.Println("ℹ️  No new invoices to import into Typesense")
+	}
+
+	// Save new sync timestamp
+	redisutil.RedisClient.Set(ctx, lastSyncKey, maxInvoiceDate.Format("2006-01-02T15:04:05"), 0)
+	finishSync(ctx, "account.move", maxWriteWatermark, false)
+	redisutil.RedisClient.Set(ctx, invoiceMoveTypesKey, moveTypes, 0)
+
+	log.Println("✅ All invoices + invoice lines synced successfully.")
+	return nil
+}
+
+var invoiceFields = []string{
+	"id", "name", "move_type", "invoice_date", "partner_id",
+	"amount_total", "payment_state", "reversed_entry_id",
+	"x_studio_related_field_6nn_1ihffsbf0",
+	"state", "write_date",
+}
+
+// invoiceMoveTypes are the customer documents the invoice sync keeps: invoices and credit notes
+var invoiceMoveTypes = []string{"out_invoice", "out_refund"}
+
+// invoiceMoveTypesKey remembers which move types the stored invoices cover
+const invoiceMoveTypesKey = "invoices:move_types"
+
+func invoiceTypeDomain() []any {
+	return []any{"move_type", "in", invoiceMoveTypes}
+}
+
+// refundSign is -1 for credit notes, so summing amounts over invoices and
+// refunds gives net sales
+func refundSign(moveType any) float64 {
+	if moveType == "out_refund" {
+		return -1
+	}
+	return 1
+}
+
+// cleanInvoice turns an Odoo invoice into the invoice document, and returns its invoice date
+func cleanInvoice(inv map[string]any) (map[string]any, time.Time) {
+	invoiceID := 0
+	if id, ok := inv["id"].(float64); ok {
+		invoiceID = int(id)
+	}
+
+	// Extract fields
+	invDate := ""
+	if inv["invoice_date"] != nil {
+		invDate = fmt.Sprintf("%v", inv["invoice_date"])
+	}
+	dtInv, _ := time.Parse("2006-01-02T15:04:05", strings.Replace(invDate, " ", "T", 1))
+	tsInv := int(dtInv.Unix())
+
+	partnerName := "NA"
+	partnerID := 0
+	if partner, ok := inv["partner_id"].([]any); ok && len(partner) >= 2 {
+		if id, ok := partner[0].(float64); ok {
+			partnerID = int(id)
+		}
+		partnerName = fmt.Sprintf("%v", partner[1])
 	}
 
-	// Add new IDs
-	for _, id := range allInvoiceIDs {
-		existingIDs[id] = true
+	salesperson := "Unknown"
+	if sp, ok := inv["x_studio_related_field_6nn_1ihffsbf0"].([]any); ok && len(sp) > 0 {
+		salesperson = fmt.Sprintf("%v", sp[0])
+	} else if sp := inv["x_studio_related_field_6nn_1ihffsbf0"]; sp != nil {
+		salesperson = fmt.Sprintf("%v", sp)
 	}
 
-	// Convert back to list
-	mergedIDs := make([]int, 0, len(existingIDs))
-	for id := range existingIDs {
-		mergedIDs = append(mergedIDs, id)
+	name := "NA"
+	if inv["name"] != nil {
+		name = fmt.Sprintf("%v", inv["name"])
 	}
 
-	// Save back
-	mergedIDsJSON, _ := json.Marshal(mergedIDs)
-	redisutil.RedisClient.Set(ctx, "invoices:all_ids", mergedIDsJSON, 0)
-	log.Printf("✅Total invoices stored: %d", len(mergedIDs))
+	amountTotal := 0.0
+	if amt, ok := inv["amount_total"].(float64); ok {
+		amountTotal = amt
+	}
 
-	// Fetch invoice lines
-	log.Printf("🔄 Fetching invoice lines for %d invoices...", len(allInvoiceIDs))
+	paymentState := "NA"
+	if inv["payment_state"] != nil {
+		paymentState = fmt.Sprintf("%v", inv["payment_state"])
+	}
+
+	// A credit note links back to the invoice it reverses
+	moveType := getString(inv["move_type"])
+	reversedID, reversedName := 0, ""
+	if reversed, ok := inv["reversed_entry_id"].([]any); ok && len(reversed) >= 2 {
+		reversedID = getInt(reversed[0])
+		reversedName = getString(reversed[1])
+	}
+
+	return map[string]any{
+		"id":                  fmt.Sprintf("%d", invoiceID),
+		"name":                name,
+		"invoice_date":        invDate,
+		"invoice_date_ts":     tsInv,
+		"partner_id":          partnerID,
+		"partner_name":        partnerName,
+		"salesperson":         salesperson,
+		"move_type":           moveType,
+		"amount_total":        amountTotal * refundSign(moveType),
+		"payment_state":       paymentState,
+		"reversed_entry_id":   reversedID,
+		"reversed_entry_name": reversedName,
+		"pdf_url":             fmt.Sprintf("%s/report/pdf/account.report_invoice/%d", config.ConfigGlobal.OdooURL, invoiceID),
+	}, dtInv
+}
+
+// saveInvoiceHeader stores the invoice document as the invoices:{id} hash
+func saveInvoiceHeader(ctx context.Context, invoiceDoc map[string]any) {
+	invoiceKey := fmt.Sprintf("invoices:%v", invoiceDoc["id"])
+	hsetMap := make(map[string]string)
+	for k, v := range invoiceDoc {
+		hsetMap[k] = fmt.Sprintf("%v", v)
+	}
+	redisutil.RedisClient.HSet(ctx, invoiceKey, hsetMap)
+}
 
+// invoiceIDsKey is the JSON list of every stored invoice id
+const invoiceIDsKey = "invoices:all_ids"
+
+// mergeInvoiceIDs adds and removes ids in invoices:all_ids and returns how many remain.
+// The invoice sync, record syncs, customer refreshes and reconcile all edit the
+// list, so the read-modify-write runs under WATCH and restarts on a conflict.
+func mergeInvoiceIDs(ctx context.Context, added, removed []int) (int, error) {
+	for attempt := 0; attempt < pageRetries; attempt++ {
+		remaining := 0
+		err := redisutil.FencedWatch(ctx, func(tx *redis.Tx) error {
+			existingIDsRaw, err := tx.Get(ctx, invoiceIDsKey).Result()
+			if err != nil && !errors.Is(err, redis.Nil) {
+				return err
+			}
+			existingIDs := make(map[int]bool)
+			if existingIDsRaw != "" {
+				var ids []int
+				if err := json.Unmarshal([]byte(existingIDsRaw), &ids); err == nil {
+					for _, id := range ids {
+						existingIDs[id] = true
+					}
+				}
+			}
+
+			// Add new IDs
+			for _, id := range added {
+				existingIDs[id] = true
+			}
+
+			// Drop invoices that are no longer posted
+			for _, id := range removed {
+				delete(existingIDs, id)
+			}
+
+			// Convert back to list
+			mergedIDs := make([]int, 0, len(existingIDs))
+			for id := range existingIDs {
+				mergedIDs = append(mergedIDs, id)
+			}
+			remaining = len(mergedIDs)
+
+			// Save back
+			mergedIDsJSON, _ := json.Marshal(mergedIDs)
+			_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+				pipe.Set(ctx, invoiceIDsKey, mergedIDsJSON, 0)
+				return nil
+			})
+			return err
+		}, invoiceIDsKey)
+		if !errors.Is(err, redis.TxFailedErr) {
+			return remaining, err
+		}
+	}
+	return 0, fmt.Errorf("%s: gave up after %d concurrent updates", invoiceIDsKey, pageRetries)
+}
+
+// syncInvoiceLines fetches the lines of the given invoices and stores them per invoice
+func syncInvoiceLines(ctx context.Context, invoiceIDs []int) {
 	batchSize := 2000
-	for i := 0; i < len(allInvoiceIDs); i += batchSize {
+	for i := 0; i < len(invoiceIDs); i += batchSize {
 		end := i + batchSize
-		if end > len(allInvoiceIDs) {
-			end = len(allInvoiceIDs)
+		if end > len(invoiceIDs) {
+			end = len(invoiceIDs)
 		}
-		batch := allInvoiceIDs[i:end]
+		batch := invoiceIDs[i:end]
 
 		req := map[string]any{
 			"jsonrpc": "2.0",
@@ -243,13 +414,13 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 					[]any{[]any{"move_id", "in", batch}},
 				},
 				"kwargs": map[string]any{
-					"fields": []string{"move_id", "product_id", "quantity", "price_total"},
+					"fields": []string{"move_id", "move_type", "product_id", "quantity", "price_total"},
 				},
 			},
 			"id": 2,
 		}
 
-		resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", req)
+		resp, err := odooRequest(ctx, req)
 		if err != nil {
 			log.Printf("❌ Failed to fetch invoice lines batch: %v", err)
 			continue
@@ -297,11 +468,13 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 				priceTotal = pt
 			}
 
+			// Credit note lines are signed like their header
+			sign := refundSign(l["move_type"])
 			bucket[invID] = append(bucket[invID], map[string]any{
 				"product":     prodName,
 				"product_id":  prodID,
-				"quantity":    quantity,
-				"price_total": priceTotal,
+				"quantity":    quantity * sign,
+				"price_total": priceTotal * sign,
 			})
 		}
 
@@ -312,50 +485,50 @@ func HandleSyncInvoicesAndLinesTask(ctx context.Context, t *asynq.Task) error {
 		}
 		log.Printf("Invoice lines fetched for batch size - %d", len(batch))
 	}
+}
 
-	// Typesense
-	if err := ensureInvoicesSchema(ctx); err != nil {
-		log.Printf("❌ Failed to ensure schema: %v", err)
-		return err
+// removeInvoices deletes cancelled or draft invoices from Redis and Typesense
+func removeInvoices(ctx context.Context, invoiceIDs []int) {
+	if len(invoiceIDs) == 0 {
+		return
 	}
 
-	if len(allInvoices) > 0 {
-		documents := make([]any, len(allInvoices))
-		for i, inv := range allInvoices {
-			documents[i] = inv
-		}
-
-		action := api.IndexAction("upsert")
-		_, err := typesenseutil.TypesenseClient.Collection("invoices").Documents().Import(ctx, documents, &api.ImportDocumentsParams{
-			Action: &action,
-		})
-		if err != nil {
-			log.Printf("❌ Failed to import invoices to Typesense: %v", err)
-		}
-	} else {
-		log.Println("ℹ️  No new invoices to import into Typesense")
+	pipe := redisutil.RedisClient.Pipeline()
+	docIDs := make([]string, len(invoiceIDs))
+	for i, id := range invoiceIDs {
+		pipe.Del(ctx, fmt.Sprintf("invoices:%d", id), fmt.Sprintf("invoice_lines:%d", id))
+		docIDs[i] = fmt.Sprintf("%d", id)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to remove cancelled invoices from Redis: %v", err)
 	}
 
-	// Save new sync timestamp
-	redisutil.RedisClient.Set(ctx, lastSyncKey, maxInvoiceDate.Format("2006-01-02T15:04:05"), 0)
+	filterBy := fmt.Sprintf("id:[%s]", strings.Join(docIDs, ","))
+	if _, err := typesenseutil.TypesenseClient.Collection("invoices").Documents().Delete(ctx, &api.DeleteDocumentsParams{
+		FilterBy: &filterBy,
+	}); err != nil {
+		log.Printf("⚠️  Failed to remove cancelled invoices from Typesense: %v", err)
+	}
 
-	log.Println("✅ All invoices + invoice lines synced successfully.")
-	MarkOrderTaskCompletion(ctx)
-	return nil
+	log.Printf("🗑️  Removed %d cancelled/draft invoices", len(invoiceIDs))
 }
 
-// ensureInvoicesSchema ensures the Typesense invoices collection schema exists
+// ensureInvoicesSchema ensures the Typesense invoices alias resolves to a collection
 func ensureInvoicesSchema(ctx context.Context) error {
-	// Try to retrieve the collection
-	_, err := typesenseutil.TypesenseClient.Collection("invoices").Retrieve(ctx)
-	if err == nil {
-		return nil
+	if err := typesenseutil.EnsureAliasedCollection(ctx, "invoices", invoicesSchema()); err != nil {
+		return fmt.Errorf("failed to create invoices collection: %w", err)
 	}
+	return nil
+}
 
-	// Collection doesn't exist, create it
+// invoicesSchema is the desired invoices schema
+func invoicesSchema() *api.CollectionSchema {
 	sortTrue := true
+	// Fields added with credit notes are optional, so collections that predate
+	// them migrate in place and older documents stay valid
+	optional := true
 	defaultSortingField := "invoice_date_ts"
-	schema := &api.CollectionSchema{
+	return &api.CollectionSchema{
 		Name: "invoices",
 		Fields: []api.Field{
 			{Name: "id", Type: "string"},
@@ -365,17 +538,13 @@ func ensureInvoicesSchema(ctx context.Context) error {
 			{Name: "partner_id", Type: "int32", Facet: &sortTrue},
 			{Name: "partner_name", Type: "string", Facet: &sortTrue},
 			{Name: "salesperson", Type: "string", Facet: &sortTrue},
+			{Name: "move_type", Type: "string", Facet: &sortTrue, Optional: &optional},
 			{Name: "amount_total", Type: "float", Sort: &sortTrue},
 			{Name: "payment_state", Type: "string", Facet: &sortTrue},
+			{Name: "reversed_entry_id", Type: "int32", Facet: &sortTrue, Optional: &optional},
+			{Name: "reversed_entry_name", Type: "string", Optional: &optional},
 			{Name: "pdf_url", Type: "string"},
 		},
 		DefaultSortingField: &defaultSortingField,
 	}
-
-	_, err = typesenseutil.TypesenseClient.Collections().Create(ctx, schema)
-	if err != nil {
-		return fmt.Errorf("failed to create invoices collection: %w", err)
-	}
-
-	return nil
 }
@@ -15,7 +15,5 @@ func HandleOrchestrateFullSyncTask(ctx context.Context, t *asynq.Task) error {
 
 	client := asynq.NewClient(asynqutil.ConnectToAsyncq(config.ConfigGlobal))
 	defer client.Close()
-	tasks.RunFullSyncOrchestration(client)
-
-	return nil
+	return tasks.RunFullSyncOrchestration(ctx, client)
 }
@@ -0,0 +1,134 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+)
+
+var orderLineFields = []string{
+	"id", "order_id", "sequence", "display_type", "product_id", "name",
+	"product_uom_qty", "qty_delivered", "qty_invoiced",
+	"price_unit", "discount", "price_subtotal",
+}
+
+// orderLinesKey holds an order's lines as a JSON array
+func orderLinesKey(orderID any) string {
+	return fmt.Sprintf("order_lines:%v", orderID)
+}
+
+// syncOrderLines fetches the lines of the given orders in batches of order ids
+// and stores them per order. Every order gets a key, so an order whose lines
+// were all deleted ends up with an empty list rather than stale lines.
+func syncOrderLines(ctx context.Context, orderIDs []int) error {
+	batchSize := 1000
+	for i := 0; i < len(orderIDs); i += batchSize {
+		end := min(i+batchSize, len(orderIDs))
+		batch := orderIDs[i:end]
+
+		lines, err := odooSearchRead(ctx, "sale.order.line", []any{
+			[]any{"order_id", "in", batch},
+		}, orderLineFields, 2000)
+		if err != nil {
+			return err
+		}
+
+		// Group lines by order ID
+		bucket := make(map[int][]map[string]any, len(batch))
+		for _, id := range batch {
+			bucket[id] = []map[string]any{}
+		}
+		for _, line := range lines {
+			// Section and note lines carry no product or amounts
+			if displayType, ok := line["display_type"].(string); ok && displayType != "" {
+				continue
+			}
+			orderID := 0
+			if order, ok := line["order_id"].([]any); ok && len(order) > 0 {
+				orderID = getInt(order[0])
+			}
+			if _, ok := bucket[orderID]; ok {
+				bucket[orderID] = append(bucket[orderID], line)
+			}
+		}
+
+		pipe := redisutil.RedisClient.Pipeline()
+		for orderID, orderLines := range bucket {
+			// Lines in the order the salesperson arranged them
+			sort.SliceStable(orderLines, func(a, b int) bool {
+				seqA, seqB := getInt(orderLines[a]["sequence"]), getInt(orderLines[b]["sequence"])
+				if seqA != seqB {
+					return seqA < seqB
+				}
+				return getInt(orderLines[a]["id"]) < getInt(orderLines[b]["id"])
+			})
+			cleaned := make([]map[string]any, len(orderLines))
+			for j, line := range orderLines {
+				cleaned[j] = cleanOrderLine(line)
+			}
+			lineJSON, _ := json.Marshal(cleaned)
+			pipe.Set(ctx, orderLinesKey(orderID), lineJSON, 0)
+		}
+		if _, err := pipe.Exec(ctx); err != nil {
+			return err
+		}
+		log.Printf("📦 Order lines - %d lines for %d orders", len(lines), len(batch))
+	}
+	return nil
+}
+
+// cleanOrderLine keeps what the order detail screen shows
+func cleanOrderLine(line map[string]any) map[string]any {
+	// Odoo sends false for an empty description
+	description, _ := line["name"].(string)
+	productID := 0
+	productName := description
+	if product, ok := line["product_id"].([]any); ok && len(product) >= 2 {
+		productID = getInt(product[0])
+		productName = getString(product[1])
+	}
+
+	return map[string]any{
+		"id":            getInt(line["id"]),
+		"product_id":    productID,
+		"product":       productName,
+		"description":   description,
+		"quantity":      getFloat(line["product_uom_qty"]),
+		"qty_delivered": getFloat(line["qty_delivered"]),
+		"qty_invoiced":  getFloat(line["qty_invoiced"]),
+		"price_unit":    getFloat(line["price_unit"]),
+		"discount":      getFloat(line["discount"]),
+		"subtotal":      getFloat(line["price_subtotal"]),
+	}
+}
+
+// removeOrderLines deletes the lines of orders that were removed
+func removeOrderLines(ctx context.Context, orderIDs []string) error {
+	for i := 0; i < len(orderIDs); i += 1000 {
+		end := min(i+1000, len(orderIDs))
+		keys := make([]string, 0, end-i)
+		for _, id := range orderIDs[i:end] {
+			keys = append(keys, orderLinesKey(id))
+		}
+		if err := redisutil.RedisClient.Del(ctx, keys...).Err(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// orderIDs returns the ids of cleaned orders
+func orderIDs(orders []map[string]any) []int {
+	ids := make([]int, 0, len(orders))
+	for _, order := range orders {
+		if id, err := strconv.Atoi(getString(order["id"])); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
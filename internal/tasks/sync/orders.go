@@ -8,8 +8,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
-	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
 	"github.com/hibiken/asynq"
 	"github.com/typesense/typesense-go/v4/typesense/api"
@@ -17,6 +15,11 @@ import (
 
 func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 	log.Println("🔄 Starting orders sync...")
+	stats := runStatsFrom(ctx)
+
+	since := syncMode(ctx, t, "sale.order", orderPages)
+	fullSync := since == ""
+	watermark := since
 
 	// Ensure schema exists
 	if err := ensureOrdersSchema(ctx); err != nil {
@@ -24,24 +27,24 @@ func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 		return err
 	}
 
-	fields := []string{
-		"id", "name", "partner_id", "amount_total", "date_order",
-		"expected_date", "amount_to_invoice",
-		"delivery_status", "amount_unpaid", "invoice_status",
-	}
-
 	limit := 1000
 	offset := 0
 	page := 1
 	totalIndexed := 0
 
-	// Calculate 6 months ago (180 days)
-	sixMonthsAgo := time.Now().UTC().AddDate(0, 0, -180)
-	domain := []any{
-		[]any{"date_order", ">", sixMonthsAgo.Format("2006-01-02 15:04:05")},
-	}
+	domain := writeDateDomain(orderDomain(), since)
 
-	pipe := redisutil.RedisClient.Pipeline()
+	var build *generationBuild
+	if fullSync {
+		var err error
+		build, err = orderPages.beginGeneration(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to start orders generation: %v", err)
+			return err
+		}
+	}
+	changedOrders := []map[string]any{}
+	fullDocuments := []any{}
 
 	for {
 		payload := map[string]any{
@@ -52,7 +55,7 @@ func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 				"method": "search_read",
 				"args":   []any{domain},
 				"kwargs": map[string]any{
-					"fields": fields,
+					"fields": orderFields,
 					"offset": offset,
 					"limit":  limit,
 				},
@@ -60,7 +63,7 @@ func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 			"id": page,
 		}
 
-		resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+		resp, err := odooRequest(ctx, payload)
 		if err != nil {
 			log.Printf("❌ Failed to fetch orders batch: %v", err)
 			return err
@@ -81,35 +84,49 @@ func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 		if len(orders) == 0 {
 			break
 		}
+		stats.page()
+		stats.processed(len(orders))
 
 		// Clean orders
 		cleaned := make([]map[string]any, 0, len(orders))
 		for _, order := range orders {
+			watermark = maxWriteDate(watermark, order)
 			cleaned = append(cleaned, cleanOrder(order))
 		}
 
-		// Redis - add to pipeline
-		cleanedJSON, err := json.Marshal(cleaned)
-		if err != nil {
-			log.Printf("⚠️  Failed to marshal orders for page %d: %v", page, err)
+		// Redis - full runs rewrite pages, incremental runs upsert after the loop
+		if fullSync {
+			if err := build.writePage(ctx, page, cleaned); err != nil {
+				log.Printf("⚠️  Failed to save orders page %d: %v", page, err)
+			}
 		} else {
-			pipe.Set(ctx, fmt.Sprintf("orders_page:%d", page), cleanedJSON, 0)
+			changedOrders = append(changedOrders, cleaned...)
+		}
+
+		// Lines of this page's orders, so order detail screens have content.
+		// Fail the run rather than let the watermark move past orders whose lines are missing
+		if err := syncOrderLines(ctx, orderIDs(cleaned)); err != nil {
+			log.Printf("❌ Failed to sync order lines of page %d: %v", page, err)
+			return err
 		}
 
-		// Typesense - import page
+		// Typesense - full runs build a new version behind the alias after the
+		// loop, incremental runs upsert each page
 		documents := make([]any, len(cleaned))
 		for i, c := range cleaned {
 			documents[i] = c
 		}
 
-		action := api.IndexAction("upsert")
-		_, err = typesenseutil.TypesenseClient.Collection("orders").Documents().Import(ctx, documents, &api.ImportDocumentsParams{
-			Action: &action,
-		})
-		if err != nil {
-			log.Printf("❌ Typesense import failed on page %d: %v", page, err)
+		if fullSync {
+			fullDocuments = append(fullDocuments, documents...)
 		} else {
-			totalIndexed += len(cleaned)
+			report, err := typesenseutil.ImportDocuments(ctx, "orders", currentRunID(ctx), documents, api.IndexAction("upsert"))
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Typesense import failed on page %d: %v", page, err)
+			} else {
+				totalIndexed += report.Imported
+			}
 		}
 
 		log.Printf("📦 Page %d synced (%d orders)", page, len(cleaned))
@@ -118,17 +135,50 @@ func HandleSyncOrdersTask(ctx context.Context, t *asynq.Task) error {
 		page++
 	}
 
-	// Execute Redis pipeline
-	_, err := pipe.Exec(ctx)
-	if err != nil {
+	if fullSync && len(fullDocuments) > 0 {
+		collectionName, report, err := typesenseutil.ReindexWithAlias(ctx, "orders", currentRunID(ctx), ordersSchema(), fullDocuments)
+		stats.imported(report)
+		if err != nil {
+			log.Printf("❌ Failed to reindex orders in Typesense: %v", err)
+			return err
+		}
+		totalIndexed = report.Imported
+		log.Printf("✅ Orders reindexed into %s", collectionName)
+	}
+
+	if fullSync {
+		if err := build.commit(ctx); err != nil {
+			log.Printf("❌ Redis couldn't commit orders generation: %v", err)
+			return err
+		}
+	} else if err := orderPages.upsert(ctx, changedOrders); err != nil {
 		log.Printf("❌ Redis couldn't save cached orders master: %v", err)
 	}
+	if total, err := orderPages.size(ctx); err == nil {
+		stats.items(total)
+	}
+
+	finishSync(ctx, "sale.order", watermark, fullSync)
 
 	log.Printf("✅ Orders sync completed. Total indexed: %d", totalIndexed)
-	MarkOrderTaskCompletion(ctx)
 	return nil
 }
 
+var orderFields = []string{
+	"id", "name", "partner_id", "amount_total", "date_order",
+	"expected_date", "amount_to_invoice",
+	"delivery_status", "amount_unpaid", "invoice_status",
+	"write_date",
+}
+
+// orderDomain selects orders of the last 6 months (180 days)
+func orderDomain() []any {
+	sixMonthsAgo := time.Now().UTC().AddDate(0, 0, -180)
+	return []any{
+		[]any{"date_order", ">", sixMonthsAgo.Format("2006-01-02 15:04:05")},
+	}
+}
+
 // cleanOrder cleans and transforms an order from Odoo format to our format
 func cleanOrder(order map[string]any) map[string]any {
 	get := func(key string) any {
@@ -185,20 +235,19 @@ func cleanOrder(order map[string]any) map[string]any {
 	}
 }
 
-// ensureOrdersSchema ensures the Typesense orders collection schema exists
+// ensureOrdersSchema ensures the Typesense orders alias resolves to a collection
 func ensureOrdersSchema(ctx context.Context) error {
-	// Try to retrieve the collection
-	_, err := typesenseutil.TypesenseClient.Collection("orders").Retrieve(ctx)
-	if err == nil {
-		return nil
+	if err := typesenseutil.EnsureAliasedCollection(ctx, "orders", ordersSchema()); err != nil {
+		return fmt.Errorf("failed to create orders collection: %w", err)
 	}
+	return nil
+}
 
-	// Collection doesn't exist, create it
-	log.Println("📦 Creating new Typesense schema for orders")
-
+// ordersSchema is the desired orders schema, applied on every full reindex
+func ordersSchema() *api.CollectionSchema {
 	sortTrue := true
 	defaultSortingField := "date_order_ts"
-	schema := &api.CollectionSchema{
+	return &api.CollectionSchema{
 		Name: "orders",
 		Fields: []api.Field{
 			{Name: "id", Type: "string"},
@@ -216,13 +265,6 @@ func ensureOrdersSchema(ctx context.Context) error {
 		},
 		DefaultSortingField: &defaultSortingField,
 	}
-
-	_, err = typesenseutil.TypesenseClient.Collections().Create(ctx, schema)
-	if err != nil {
-		return fmt.Errorf("failed to create orders collection: %w", err)
-	}
-
-	return nil
 }
 
 // getStringOrNA returns "NA" if value is nil or empty, otherwise returns string representation
@@ -0,0 +1,373 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+const (
+	// paymentIDsKey is the set of payment ids indexed by the last run
+	paymentIDsKey = "payments:ids"
+	// paymentPartnersKey is the set of partners with a payments list
+	paymentPartnersKey = "payments:partners"
+)
+
+// paymentsKey holds a partner's payments as JSON entries, newest first
+func paymentsKey(partnerID any) string {
+	return fmt.Sprintf("payments:customer:%v", partnerID)
+}
+
+// settledPaymentStates are the payment states that count as money received:
+// posted (Odoo 17) and in_process/paid (Odoo 18). Drafts, canceled and
+// rejected payments stay out of the index and the per-customer lists.
+var settledPaymentStates = []string{"posted", "in_process", "paid"}
+
+// paymentFields are read from every payment, plus the memo field paymentMemoField picks
+var paymentFields = []string{
+	"id", "name", "date", "amount", "currency_id", "partner_id",
+	"journal_id", "payment_method_line_id", "state",
+	"reconciled_invoice_ids",
+}
+
+// paymentMemoField returns the payment memo field of the connected Odoo: memo
+// since Odoo 18, ref before. fields_get only lists the fields that exist.
+func paymentMemoField(ctx context.Context) (string, error) {
+	payload := map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "call",
+		"params": map[string]any{
+			"model":  "account.payment",
+			"method": "fields_get",
+			"args":   []any{},
+			"kwargs": map[string]any{
+				"allfields":  []string{"memo", "ref"},
+				"attributes": []string{"type"},
+			},
+		},
+		"id": 2,
+	}
+
+	resp, err := odooRequest(ctx, payload)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result map[string]any `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return "", err
+	}
+	if _, ok := rpcResp.Result["memo"]; ok {
+		return "memo", nil
+	}
+	return "ref", nil
+}
+
+// HandleSyncPaymentsTask re-reads inbound customer payments of the last
+// PAYMENTS_SYNC_MONTHS months with the invoices they settled, indexes them into
+// the payments collection and rewrites each partner's payments list
+func HandleSyncPaymentsTask(ctx context.Context, t *asynq.Task) error {
+	log.Println("🔄 Starting payments sync...")
+	stats := runStatsFrom(ctx)
+	startTime := time.Now()
+	fullSync := fullSyncRequested(t, "account.payment")
+
+	if err := ensurePaymentsSchema(ctx); err != nil {
+		log.Printf("❌ Failed to ensure schema: %v", err)
+		return err
+	}
+
+	since := time.Now().UTC().AddDate(0, -paymentsSyncMonths(), 0).Format("2006-01-02")
+	domain := []any{
+		[]any{"payment_type", "=", "inbound"},
+		[]any{"partner_type", "=", "customer"},
+		[]any{"state", "in", settledPaymentStates},
+		[]any{"date", ">=", since},
+	}
+
+	memoField, err := paymentMemoField(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to read payment fields: %v", err)
+		return err
+	}
+	fields := append(append([]string{}, paymentFields...), memoField)
+
+	byPartner := make(map[int][]map[string]any)
+	liveIDs := []string{}
+	fullDocuments := []any{}
+	pageNo := 0
+	// Newest first, so each partner's list comes out in date order
+	err = odooSearchReadPages(ctx, "account.payment", domain, fields, "date desc, id desc", 1000, func(page []map[string]any) error {
+		pageNo++
+		stats.page()
+
+		invoiceNames, err := fetchMoveNames(ctx, page)
+		if err != nil {
+			return err
+		}
+
+		docs := make([]any, 0, len(page))
+		for _, payment := range page {
+			doc := cleanPayment(payment, invoiceNames)
+			partnerID := doc["partner_id"].(int)
+			if partnerID == 0 {
+				stats.skipped(1)
+				continue
+			}
+			byPartner[partnerID] = append(byPartner[partnerID], doc)
+			liveIDs = append(liveIDs, doc["id"].(string))
+			docs = append(docs, doc)
+		}
+		stats.processed(len(docs))
+
+		// Full runs build a new version behind the alias once every page is in
+		if fullSync {
+			fullDocuments = append(fullDocuments, docs...)
+			return nil
+		}
+		report, err := typesenseutil.ImportDocuments(ctx, "payments", currentRunID(ctx), docs, api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			log.Printf("❌ Typesense import failed on payments page %d: %v", pageNo, err)
+		}
+		log.Printf("💳 Payments - page %d synced (%d payments)", pageNo, len(docs))
+		return nil
+	})
+	if err != nil {
+		log.Printf("❌ Failed to fetch payments: %v", err)
+		return err
+	}
+
+	if fullSync && len(fullDocuments) > 0 {
+		collectionName, report, err := typesenseutil.ReindexWithAlias(ctx, "payments", currentRunID(ctx), paymentsSchema(), fullDocuments)
+		stats.imported(report)
+		if err != nil {
+			log.Printf("❌ Failed to reindex payments in Typesense: %v", err)
+			return err
+		}
+		log.Printf("✅ Payments reindexed into %s", collectionName)
+	}
+
+	if err := savePartnerPayments(ctx, byPartner); err != nil {
+		log.Printf("❌ Redis couldn't save payment lists: %v", err)
+		return err
+	}
+	if err := pruneTypesensePayments(ctx, liveIDs); err != nil {
+		log.Printf("⚠️  Failed to remove stale payments: %v", err)
+	}
+
+	stats.items(int64(len(liveIDs)))
+	log.Printf("✅ Payments sync complete — %d payments for %d customers in %.2fs",
+		len(liveIDs), len(byPartner), time.Since(startTime).Seconds())
+	return nil
+}
+
+// fetchMoveNames resolves the reconciled invoice ids of a page of payments to their names
+func fetchMoveNames(ctx context.Context, payments []map[string]any) (map[int]string, error) {
+	ids := []int{}
+	seen := make(map[int]bool)
+	for _, payment := range payments {
+		for _, id := range intList(payment["reconciled_invoice_ids"]) {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	names := make(map[int]string, len(ids))
+	if len(ids) == 0 {
+		return names, nil
+	}
+	moves, err := odooSearchRead(ctx, "account.move", []any{[]any{"id", "in", ids}}, []string{"id", "name"}, 2000)
+	if err != nil {
+		return nil, err
+	}
+	for _, move := range moves {
+		names[getInt(move["id"])] = getString(move["name"])
+	}
+	return names, nil
+}
+
+// cleanPayment turns an Odoo payment into the payment document
+func cleanPayment(payment map[string]any, invoiceNames map[int]string) map[string]any {
+	partnerID, partnerName := 0, "NA"
+	if partner, ok := payment["partner_id"].([]any); ok && len(partner) >= 2 {
+		partnerID = getInt(partner[0])
+		partnerName = getString(partner[1])
+	}
+
+	date := getString(payment["date"])
+	var dateTs int64
+	if parsed, err := time.Parse("2006-01-02", date); err == nil {
+		dateTs = parsed.Unix()
+	}
+
+	invoiceIDs := intList(payment["reconciled_invoice_ids"])
+	invoices := make([]string, len(invoiceIDs))
+	for i, id := range invoiceIDs {
+		invoices[i] = invoiceNames[id]
+	}
+
+	// Odoo sends false for an empty memo; it is memo since Odoo 18, ref before
+	memo, _ := payment["memo"].(string)
+	if _, ok := payment["memo"]; !ok {
+		memo, _ = payment["ref"].(string)
+	}
+
+	return map[string]any{
+		"id":                       strconv.Itoa(getInt(payment["id"])),
+		"name":                     getStringOrNA(payment["name"]),
+		"partner_id":               partnerID,
+		"partner_name":             partnerName,
+		"date":                     date,
+		"date_ts":                  dateTs,
+		"amount":                   getFloat(payment["amount"]),
+		"currency":                 many2oneName(payment["currency_id"]),
+		"journal":                  many2oneName(payment["journal_id"]),
+		"payment_method":           many2oneName(payment["payment_method_line_id"]),
+		"memo":                     memo,
+		"state":                    getStringOrNA(payment["state"]),
+		"reconciled_invoice_ids":   invoiceIDs,
+		"reconciled_invoice_names": invoices,
+	}
+}
+
+// savePartnerPayments rewrites the payments list of every partner seen in
+// this run and drops the lists of partners that no longer have payments
+func savePartnerPayments(ctx context.Context, byPartner map[int][]map[string]any) error {
+	// Refuse to wipe every list if Odoo returned nothing
+	if len(byPartner) == 0 {
+		return nil
+	}
+
+	previous, err := redisutil.RedisClient.SMembers(ctx, paymentPartnersKey).Result()
+	if err != nil {
+		return err
+	}
+
+	partners := make([]any, 0, len(byPartner))
+	pipe := redisutil.RedisClient.TxPipeline()
+	for partnerID, payments := range byPartner {
+		entries := make([]any, len(payments))
+		for i, payment := range payments {
+			entry, _ := json.Marshal(payment)
+			entries[i] = entry
+		}
+		pipe.Del(ctx, paymentsKey(partnerID))
+		pipe.RPush(ctx, paymentsKey(partnerID), entries...)
+		partners = append(partners, strconv.Itoa(partnerID))
+	}
+	for _, partnerID := range previous {
+		if id, err := strconv.Atoi(partnerID); err != nil || byPartner[id] == nil {
+			pipe.Del(ctx, paymentsKey(partnerID))
+		}
+	}
+	pipe.Del(ctx, paymentPartnersKey)
+	if len(partners) > 0 {
+		pipe.SAdd(ctx, paymentPartnersKey, partners...)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+// pruneTypesensePayments deletes documents indexed by the previous run that
+// this run no longer returned: deleted, reset to draft or out of the window
+func pruneTypesensePayments(ctx context.Context, liveIDs []string) error {
+	previous, err := redisutil.RedisClient.SMembers(ctx, paymentIDsKey).Result()
+	if err != nil {
+		return err
+	}
+	live := make(map[string]bool, len(liveIDs))
+	for _, id := range liveIDs {
+		live[id] = true
+	}
+	stale := staleIDs(previous, live)
+	if err := deleteTypesenseDocuments(ctx, "payments", stale); err != nil {
+		return err
+	}
+
+	members := make([]any, len(liveIDs))
+	for i, id := range liveIDs {
+		members[i] = id
+	}
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.Del(ctx, paymentIDsKey)
+	if len(members) > 0 {
+		pipe.SAdd(ctx, paymentIDsKey, members...)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func paymentsSyncMonths() int {
+	months, err := strconv.Atoi(config.ConfigGlobal.PaymentsSyncMonths)
+	if err != nil || months <= 0 {
+		months = 6
+	}
+	return months
+}
+
+// many2oneName reads the display name of an Odoo many2one value ([id, name] or false)
+func many2oneName(v any) string {
+	if pair, ok := v.([]any); ok && len(pair) >= 2 {
+		return getString(pair[1])
+	}
+	return ""
+}
+
+// intList reads an Odoo x2many value as ids
+func intList(v any) []int {
+	items, _ := v.([]any)
+	ids := make([]int, 0, len(items))
+	for _, item := range items {
+		ids = append(ids, getInt(item))
+	}
+	return ids
+}
+
+// ensurePaymentsSchema ensures the Typesense payments alias resolves to a collection
+func ensurePaymentsSchema(ctx context.Context) error {
+	if err := typesenseutil.EnsureAliasedCollection(ctx, "payments", paymentsSchema()); err != nil {
+		return fmt.Errorf("failed to create payments collection: %w", err)
+	}
+	return nil
+}
+
+// paymentsSchema is the desired payments schema, applied on every full reindex
+func paymentsSchema() *api.CollectionSchema {
+	sortTrue := true
+	defaultSortingField := "date_ts"
+	return &api.CollectionSchema{
+		Name: "payments",
+		Fields: []api.Field{
+			{Name: "id", Type: "string"},
+			{Name: "name", Type: "string"},
+			{Name: "partner_id", Type: "int32", Facet: &sortTrue},
+			{Name: "partner_name", Type: "string", Facet: &sortTrue},
+			{Name: "date", Type: "string"},
+			{Name: "date_ts", Type: "int64", Sort: &sortTrue},
+			{Name: "amount", Type: "float", Sort: &sortTrue},
+			{Name: "currency", Type: "string", Facet: &sortTrue},
+			{Name: "journal", Type: "string", Facet: &sortTrue},
+			{Name: "payment_method", Type: "string", Facet: &sortTrue},
+			{Name: "memo", Type: "string"},
+			{Name: "state", Type: "string", Facet: &sortTrue},
+			{Name: "reconciled_invoice_ids", Type: "int32[]", Facet: &sortTrue},
+			{Name: "reconciled_invoice_names", Type: "string[]"},
+		},
+		DefaultSortingField: &defaultSortingField,
+	}
+}
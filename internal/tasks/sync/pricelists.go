@@ -8,14 +8,13 @@ import (
 	"strconv"
 	"time"
 
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	"github.com/hibiken/asynq"
 )
 
 func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
-	defer MarkCoreTaskCompletion(ctx)
 	log.Println("🔄 Starting pricelist sync...")
+	stats := runStatsFrom(ctx)
 	startTime := time.Now()
 
 	offset, limit := 0, 1000
@@ -23,7 +22,7 @@ func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
 	batchNo := 0
 
 	// Fetch pricelist master
-	pricelistMaster, err := fetchPricelistMaster()
+	pricelistMaster, err := fetchPricelistMaster(ctx)
 	if err != nil {
 		log.Printf("❌ Failed to fetch pricelist master: %v", err)
 		return err
@@ -52,7 +51,7 @@ func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
 
 	// Fetch and process pricelist items in batches
 	for {
-		batch, err := fetchPricelists(offset, limit)
+		batch, err := fetchPricelists(ctx, offset, limit)
 		if err != nil {
 			log.Printf("❌ Failed to fetch pricelists batch: %v", err)
 			return err
@@ -61,6 +60,7 @@ func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
 		if len(batch) == 0 {
 			break
 		}
+		stats.page()
 
 		// Use Redis pipeline for batch operations
 		pipe := redisutil.RedisClient.Pipeline()
@@ -70,6 +70,7 @@ func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
 			redisKey, mapping, err := processPricelistItem(prConfig)
 			if err != nil {
 				log.Printf("⚠️  Skipping invalid record at offset %d: %v", offset, err)
+				stats.skipped(1)
 				continue
 			}
 
@@ -93,19 +94,21 @@ func HandleSyncPricelistsTask(ctx context.Context, t *asynq.Task) error {
 		}
 
 		totalCount += processedInBatch
+		stats.processed(processedInBatch)
 		batchNo++
 		log.Printf("✅ Pricelist - Batch %d done | Total synced: %d", batchNo, totalCount)
 
 		offset += limit
 	}
 
+	stats.items(int64(totalCount))
 	duration := time.Since(startTime)
 	log.Printf("✅ Pricelist - Sync complete — %d records in %.2fs", totalCount, duration.Seconds())
 	return nil
 }
 
 // fetchPricelistMaster fetches the pricelist master data from Odoo
-func fetchPricelistMaster() (map[int]string, error) {
+func fetchPricelistMaster(ctx context.Context) (map[int]string, error) {
 	payload := map[string]any{
 		"jsonrpc": "2.0",
 		"method":  "call",
@@ -124,7 +127,7 @@ func fetchPricelistMaster() (map[int]string, error) {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -149,7 +152,7 @@ func fetchPricelistMaster() (map[int]string, error) {
 }
 
 // fetchPricelists fetches pricelist items from Odoo in batches
-func fetchPricelists(offset, limit int) ([]map[string]any, error) {
+func fetchPricelists(ctx context.Context, offset, limit int) ([]map[string]any, error) {
 	payload := map[string]any{
 		"jsonrpc": "2.0",
 		"method":  "call",
@@ -170,7 +173,7 @@ func fetchPricelists(offset, limit int) ([]map[string]any, error) {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
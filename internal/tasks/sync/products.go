@@ -9,7 +9,6 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
 	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
 	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
 	"github.com/hibiken/asynq"
@@ -17,54 +16,33 @@ import (
 )
 
 func HandleSyncProductsTask(ctx context.Context, task *asynq.Task) error {
-	defer MarkCoreTaskCompletion(ctx)
 	log.Println("🔄 Starting products sync...")
+	stats := runStatsFrom(ctx)
 
-	// First fetch all product categories
-	if err := GetProductCategories(ctx); err != nil {
-		log.Printf("❌ Failed to fetch product categories: %v", err)
-		return err
-	}
-
-	allProducts := []map[string]any{}
-	offset, limit, pageNum := 0, 250, 1
-	letterPattern := regexp.MustCompile(`[A-Za-z]`)
-
-	// Fetch auxiliary data
-	productTags, err := fetchProductTags(ctx)
-	if err != nil {
-		log.Printf("❌ Failed to fetch product tags: %v", err)
-		return err
-	}
+	since := syncMode(ctx, task, "product.product", productPages)
+	fullSync := since == ""
+	watermark := since
 
-	productTaxes, err := fetchProductTaxes(ctx)
+	lookups, err := fetchProductLookups(ctx)
 	if err != nil {
-		log.Printf("❌ Failed to fetch product taxes: %v", err)
 		return err
 	}
 
-	caseBarcodes, err := fetchCaseBarcode(ctx)
-	if err != nil {
-		log.Printf("❌ Failed to fetch case barcodes: %v", err)
-		return err
-	}
-
-	// Get product category parent path from Redis
-	parentPathData, err := redisutil.RedisClient.Get(ctx, "product_cat_parent_path").Result()
-	if err != nil {
-		log.Printf("❌ Failed to get product_cat_parent_path from Redis: %v", err)
-		return err
-	}
+	allProducts := []map[string]any{}
+	offset, limit, pageNum := 0, 250, 1
 
-	var idToParentPath map[string][]int
-	if err := json.Unmarshal([]byte(parentPathData), &idToParentPath); err != nil {
-		log.Printf("❌ Failed to unmarshal parent path: %v", err)
-		return err
+	var build *generationBuild
+	if fullSync {
+		build, err = productPages.beginGeneration(ctx)
+		if err != nil {
+			log.Printf("❌ Failed to start products generation: %v", err)
+			return err
+		}
 	}
 
 	// Fetch products in batches
 	for {
-		batch, err := fetchProducts(ctx, offset, limit)
+		batch, err := fetchProducts(ctx, since, offset, limit)
 		if err != nil {
 			log.Printf("❌ Failed to fetch products batch: %v", err)
 			return err
@@ -73,30 +51,40 @@ func HandleSyncProductsTask(ctx context.Context, task *asynq.Task) error {
 		if len(batch) == 0 {
 			break
 		}
+		stats.page()
 
 		batchProducts := []map[string]any{}
 		for _, product := range batch {
-			// Skip products with letters in default_code
-			defaultCode, _ := product["default_code"].(string)
-			if letterPattern.MatchString(defaultCode) {
+			watermark = maxWriteDate(watermark, product)
+
+			if skipProduct(product) {
+				stats.skipped(1)
 				continue
 			}
 
-			cleanedProduct := cleanProduct(product, productTags, productTaxes, caseBarcodes, idToParentPath)
+			cleanedProduct := lookups.clean(product)
 			batchProducts = append(batchProducts, cleanedProduct)
 			allProducts = append(allProducts, cleanedProduct)
 		}
 
-		// Save paginated data in Redis
-		batchJSON, err := json.Marshal(batchProducts)
-		if err != nil {
-			log.Printf("❌ Failed to marshal batch products: %v", err)
-			return err
+		// Incremental runs also select variants by their template's write_date, so
+		// the watermark has to move past it or they are re-read on every run
+		if !fullSync {
+			watermark, err = templateWatermark(ctx, watermark, batch)
+			if err != nil {
+				log.Printf("❌ Failed to fetch product template write dates: %v", err)
+				return err
+			}
 		}
 
-		if err := redisutil.RedisClient.Set(ctx, fmt.Sprintf("products:%d", pageNum), batchJSON, 0).Err(); err != nil {
-			log.Printf("❌ Failed to save batch to Redis: %v", err)
-			return err
+		stats.processed(len(batchProducts))
+
+		// Save paginated data in Redis (incremental runs upsert in place below)
+		if fullSync {
+			if err := build.writePage(ctx, pageNum, batchProducts); err != nil {
+				log.Printf("❌ Failed to save batch to Redis: %v", err)
+				return err
+			}
 		}
 
 		log.Printf("📦 Products - Batch %d done", pageNum)
@@ -105,10 +93,31 @@ func HandleSyncProductsTask(ctx context.Context, task *asynq.Task) error {
 		pageNum++
 	}
 
-	// Save product count in Redis
-	if err := redisutil.RedisClient.Set(ctx, "products:total", len(allProducts), 0).Err(); err != nil {
-		log.Printf("❌ Failed to save product count: %v", err)
-		return err
+	if fullSync {
+		// Verify the new generation and flip products:current to it
+		if err := build.commit(ctx); err != nil {
+			log.Printf("❌ Failed to commit products generation: %v", err)
+			return err
+		}
+
+		// Save product count in Redis
+		if err := redisutil.RedisClient.Set(ctx, "products:total", build.records(), 0).Err(); err != nil {
+			log.Printf("❌ Failed to save product count: %v", err)
+			return err
+		}
+		stats.items(int64(build.records()))
+	} else {
+		if err := productPages.upsert(ctx, allProducts); err != nil {
+			log.Printf("❌ Failed to upsert changed products in Redis: %v", err)
+			return err
+		}
+
+		total, _ := productPages.size(ctx)
+		if err := redisutil.RedisClient.Set(ctx, "products:total", total, 0).Err(); err != nil {
+			log.Printf("❌ Failed to save product count: %v", err)
+			return err
+		}
+		stats.items(total)
 	}
 
 	// Ensure Typesense schema exists
@@ -117,40 +126,126 @@ func HandleSyncProductsTask(ctx context.Context, task *asynq.Task) error {
 		return err
 	}
 
-	// Wipe old docs and bulk import
+	// Full runs build a new version behind the alias, incremental runs upsert what changed
 	if len(allProducts) > 0 {
-		// Delete old documents
-		filterBy := "product_id:>0"
-		_, err := typesenseutil.TypesenseClient.Collection("products").Documents().Delete(ctx, &api.DeleteDocumentsParams{
-			FilterBy: &filterBy,
-		})
-		if err != nil {
-			log.Printf("⚠️  Could not delete old documents (collection may not exist): %v", err)
-		}
-
 		// Convert to []any for Import
 		documents := make([]any, len(allProducts))
 		for i, p := range allProducts {
 			documents[i] = p
 		}
 
-		// Bulk import
-		action := api.IndexAction("upsert")
-		importResult, err := typesenseutil.TypesenseClient.Collection("products").Documents().Import(ctx, documents, &api.ImportDocumentsParams{
-			Action: &action,
-		})
-		if err != nil {
-			log.Printf("❌ Failed to import products to Typesense: %v", err)
-			return err
-		}
+		if fullSync {
+			collectionName, report, err := typesenseutil.ReindexWithAlias(ctx, "products", currentRunID(ctx), productsSchema(), documents)
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to reindex products in Typesense: %v", err)
+				return err
+			}
+
+			log.Printf("✅ Synced %d products into Redis + Typesense (%s)", len(allProducts), collectionName)
+		} else {
+			// Bulk import
+			report, err := typesenseutil.ImportDocuments(ctx, "products", currentRunID(ctx), documents, api.IndexAction("upsert"))
+			stats.imported(report)
+			if err != nil {
+				log.Printf("❌ Failed to import products to Typesense: %v", err)
+				return err
+			}
 
-		log.Printf("✅ Synced %d products into Redis + Typesense. Imported %d documents", len(allProducts), len(importResult))
+			log.Printf("✅ Synced %d products into Redis + Typesense. Imported %d documents, %d failed", len(allProducts), report.Imported, report.Failed)
+		}
 	}
 
+	finishSync(ctx, "product.product", watermark, fullSync)
 	log.Println("✅ Products sync completed!")
 	return nil
 }
 
+// productLookups holds the auxiliary data cleanProduct resolves ids against
+type productLookups struct {
+	tags         map[int]string
+	taxes        map[int][2]any
+	caseBarcodes map[int]string
+	parentPaths  map[string][]int
+}
+
+// fetchProductLookups refreshes categories, tags, taxes and packaging barcodes
+func fetchProductLookups(ctx context.Context) (*productLookups, error) {
+	// First fetch all product categories
+	if err := GetProductCategories(ctx); err != nil {
+		log.Printf("❌ Failed to fetch product categories: %v", err)
+		return nil, err
+	}
+
+	productTags, err := fetchProductTags(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to fetch product tags: %v", err)
+		return nil, err
+	}
+
+	productTaxes, err := fetchProductTaxes(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to fetch product taxes: %v", err)
+		return nil, err
+	}
+
+	caseBarcodes, err := fetchCaseBarcode(ctx)
+	if err != nil {
+		log.Printf("❌ Failed to fetch case barcodes: %v", err)
+		return nil, err
+	}
+
+	// Get product category parent path from Redis
+	parentPathData, err := redisutil.RedisClient.Get(ctx, "product_cat_parent_path").Result()
+	if err != nil {
+		log.Printf("❌ Failed to get product_cat_parent_path from Redis: %v", err)
+		return nil, err
+	}
+
+	var idToParentPath map[string][]int
+	if err := json.Unmarshal([]byte(parentPathData), &idToParentPath); err != nil {
+		log.Printf("❌ Failed to unmarshal parent path: %v", err)
+		return nil, err
+	}
+
+	return &productLookups{
+		tags:         productTags,
+		taxes:        productTaxes,
+		caseBarcodes: caseBarcodes,
+		parentPaths:  idToParentPath,
+	}, nil
+}
+
+func (lookups *productLookups) clean(product map[string]any) map[string]any {
+	return cleanProduct(product, lookups.tags, lookups.taxes, lookups.caseBarcodes, lookups.parentPaths)
+}
+
+var letterPattern = regexp.MustCompile(`[A-Za-z]`)
+
+// skipProduct drops products with letters in default_code
+func skipProduct(product map[string]any) bool {
+	defaultCode, _ := product["default_code"].(string)
+	return letterPattern.MatchString(defaultCode)
+}
+
+// productDomain selects the saleable products the sync keeps
+func productDomain() []any {
+	return []any{
+		[]any{"list_price", ">", 0.5},
+		[]any{"sale_ok", "=", true},
+		[]any{"active", "=", true},
+	}
+}
+
+var productFields = []string{
+	"id", "product_tmpl_id", "categ_id", "name", "list_price", "standard_price",
+	"x_studio_image_url", "x_studio_msl_to_customer", "x_studio_msl_to_cn",
+	"x_studio_storage", "uom_id", "qty_available", "outgoing_qty", "incoming_qty", "product_tag_ids",
+	"public_categ_ids", "barcode", "x_studio_units_per_case", "x_studio_rrp",
+	"taxes_id", "default_code", "website_sequence", "x_studio_brand_name", "weight",
+	"write_date",
+}
+
 type ProductCategory struct {
 	ID         int    `json:"id"`
 	Name       string `json:"name"`
@@ -180,7 +275,7 @@ func GetProductCategories(ctx context.Context) error {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return err
 	}
@@ -272,7 +367,7 @@ func fetchProductTags(ctx context.Context) (map[int]string, error) {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -320,7 +415,7 @@ func fetchProductTaxes(ctx context.Context) (map[int][2]any, error) {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -361,7 +456,7 @@ func fetchCaseBarcode(ctx context.Context) (map[int]string, error) {
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -407,14 +502,48 @@ func fetchCaseBarcode(ctx context.Context) (map[int]string, error) {
 	return caseBarcode, nil
 }
 
-// fetchProducts fetches products from Odoo in batches
-func fetchProducts(ctx context.Context, offset, limit int) ([]map[string]any, error) {
-	fields := []string{
-		"id", "product_tmpl_id", "categ_id", "name", "list_price", "standard_price",
-		"x_studio_image_url", "x_studio_msl_to_customer", "x_studio_msl_to_cn",
-		"x_studio_storage", "uom_id", "qty_available", "outgoing_qty", "product_tag_ids",
-		"public_categ_ids", "barcode", "x_studio_units_per_case", "x_studio_rrp",
-		"taxes_id", "default_code", "website_sequence", "x_studio_brand_name", "weight",
+// templateWatermark advances the watermark with the write_date of the templates
+// of a batch of variants
+func templateWatermark(ctx context.Context, watermark string, batch []map[string]any) (string, error) {
+	ids := []int{}
+	seen := make(map[int]bool)
+	for _, product := range batch {
+		pair, ok := product["product_tmpl_id"].([]any)
+		if !ok || len(pair) == 0 {
+			continue
+		}
+		if id := getInt(pair[0]); id > 0 && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return watermark, nil
+	}
+
+	templates, err := odooSearchRead(ctx, "product.template", []any{
+		[]any{"id", "in", ids},
+	}, []string{"write_date"}, 1000)
+	if err != nil {
+		return watermark, err
+	}
+	for _, template := range templates {
+		watermark = maxWriteDate(watermark, template)
+	}
+	return watermark, nil
+}
+
+// fetchProducts fetches products from Odoo in batches.
+// A non-empty since limits the result to variants or templates written since then.
+func fetchProducts(ctx context.Context, since string, offset, limit int) ([]map[string]any, error) {
+	domain := productDomain()
+	if since != "" {
+		// Price and most catalogue fields live on the template, so watch both write dates
+		domain = append(domain,
+			"|",
+			[]any{"write_date", ">=", since},
+			[]any{"product_tmpl_id.write_date", ">=", since},
+		)
 	}
 
 	payload := map[string]any{
@@ -423,15 +552,9 @@ func fetchProducts(ctx context.Context, offset, limit int) ([]map[string]any, er
 		"params": map[string]any{
 			"model":  "product.product",
 			"method": "search_read",
-			"args": []any{
-				[]any{
-					[]any{"list_price", ">", 0.5},
-					[]any{"sale_ok", "=", true},
-					[]any{"active", "=", true},
-				},
-			},
+			"args":   []any{domain},
 			"kwargs": map[string]any{
-				"fields": fields,
+				"fields": productFields,
 				"offset": offset,
 				"limit":  limit,
 				"order":  "website_sequence asc",
@@ -440,7 +563,7 @@ func fetchProducts(ctx context.Context, offset, limit int) ([]map[string]any, er
 		"id": 2,
 	}
 
-	resp, err := odoo.OdooManager.NewRequest("POST", "web/dataset/call_kw", payload)
+	resp, err := odooRequest(ctx, payload)
 	if err != nil {
 		return nil, err
 	}
@@ -605,6 +728,7 @@ func cleanProduct(product map[string]any, productTags map[int]string, productTax
 		"uom":              getName(product["uom_id"]),
 		"qty_available":    getInt(product["qty_available"]) - getInt(product["outgoing_qty"]),
 		"outgoing_qty":     getInt(product["outgoing_qty"]),
+		"incoming_qty":     getInt(product["incoming_qty"]),
 		"product_tags":     productTagsList,
 		"barcode":          barcode,
 		"units_per_case":   getInt(product["x_studio_units_per_case"]),
@@ -622,21 +746,20 @@ func cleanProduct(product map[string]any, productTags map[int]string, productTax
 	return cleaned
 }
 
-// ensureProductsSchema ensures the Typesense products collection schema exists
+// ensureProductsSchema ensures the Typesense products alias resolves to a collection
 func ensureProductsSchema(ctx context.Context) error {
-	// Try to retrieve the collection
-	_, err := typesenseutil.TypesenseClient.Collection("products").Retrieve(ctx)
-	if err == nil {
-		log.Println("✅ Products collection exists")
-		return nil
+	if err := typesenseutil.EnsureAliasedCollection(ctx, "products", productsSchema()); err != nil {
+		return fmt.Errorf("failed to create products collection: %w", err)
 	}
+	return nil
+}
 
-	// Collection doesn't exist, create it
-	log.Println("⚠️  Products collection missing, creating...")
-
+// productsSchema is the desired products schema. Full syncs build every new
+// version from it, so schema changes go live through the alias switch.
+func productsSchema() *api.CollectionSchema {
 	sortTrue := true
 	defaultSortingField := "website_sequence"
-	schema := &api.CollectionSchema{
+	return &api.CollectionSchema{
 		Name: "products",
 		Fields: []api.Field{
 			{Name: "id", Type: "string"},
@@ -653,6 +776,7 @@ func ensureProductsSchema(ctx context.Context) error {
 			{Name: "uom", Type: "string"},
 			{Name: "qty_available", Type: "int32"},
 			{Name: "outgoing_qty", Type: "int32"},
+			{Name: "incoming_qty", Type: "int32"},
 			{Name: "product_tags", Type: "string[]", Facet: &sortTrue},
 			{Name: "units_per_case", Type: "int32"},
 			{Name: "barcode", Type: "string", Infix: &sortTrue},
@@ -668,12 +792,4 @@ func ensureProductsSchema(ctx context.Context) error {
 		},
 		DefaultSortingField: &defaultSortingField,
 	}
-
-	_, err = typesenseutil.TypesenseClient.Collections().Create(ctx, schema)
-	if err != nil {
-		return fmt.Errorf("failed to create products collection: %w", err)
-	}
-
-	log.Println("✅ Products collection created")
-	return nil
 }
@@ -0,0 +1,337 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+// reconcileWindowDays matches the 180 day window the order and invoice syncs fetch
+const reconcileWindowDays = 180
+
+// HandleSyncReconcileTask removes Redis keys and Typesense documents for records
+// that were deleted or archived in Odoo, or fell out of the sync window
+func HandleSyncReconcileTask(ctx context.Context, t *asynq.Task) error {
+	log.Println("🔄 Starting deletion reconciliation...")
+
+	reconcilers := []struct {
+		entity string
+		run    func(ctx context.Context) (int, error)
+	}{
+		{"products", reconcileProducts},
+		{"customers", reconcileCustomers},
+		{"customer_statements", reconcileCustomerStatements},
+		{"pricelists", reconcilePricelists},
+		{"orders", reconcileOrders},
+		{"invoices", reconcileInvoices},
+	}
+
+	purged := make(map[string]any, len(reconcilers))
+	var firstErr error
+	for _, r := range reconcilers {
+		count, err := r.run(ctx)
+		if err != nil {
+			log.Printf("❌ Reconcile %s failed: %v", r.entity, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		purged[r.entity] = count
+		runStatsFrom(ctx).processed(count)
+		log.Printf("🧹 Reconcile %s - purged %d", r.entity, count)
+	}
+
+	purged["finished_at"] = time.Now().UTC().Format(time.RFC3339)
+	redisutil.RedisClient.HSet(ctx, "sync:reconcile:last", purged)
+
+	if firstErr != nil {
+		return firstErr
+	}
+	log.Println("✅ Deletion reconciliation completed!")
+	return nil
+}
+
+// reconcileProducts purges products that are archived, no longer saleable or deleted
+func reconcileProducts(ctx context.Context) (int, error) {
+	liveIDs, err := odooSearchIDs(ctx, "product.product", productDomain())
+	if err != nil {
+		return 0, err
+	}
+
+	storedIDs, err := productPages.ids(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stale := staleIDs(storedIDs, liveIDs)
+	if err := productPages.remove(ctx, stale); err != nil {
+		return 0, err
+	}
+	if err := deleteTypesenseDocuments(ctx, "products", stale); err != nil {
+		return 0, err
+	}
+
+	total, _ := productPages.size(ctx)
+	redisutil.RedisClient.Set(ctx, "products:total", total, 0)
+	return len(stale), nil
+}
+
+// reconcileCustomers purges partners that were archived, merged or lost their customer rank
+func reconcileCustomers(ctx context.Context) (int, error) {
+	liveIDs, err := odooSearchIDs(ctx, "res.partner", []any{
+		[]any{"customer_rank", ">", 0},
+		[]any{"active", "=", true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	storedIDs, err := redisutil.RedisClient.SMembers(ctx, "customers").Result()
+	if err != nil {
+		return 0, err
+	}
+
+	stale := staleIDs(storedIDs, liveIDs)
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	if err := removeCustomers(ctx, stale); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// reconcileCustomerStatements purges statements of partners that are no longer live customers
+func reconcileCustomerStatements(ctx context.Context) (int, error) {
+	liveIDs, err := odooSearchIDs(ctx, "res.partner", []any{
+		[]any{"customer_rank", ">", 0},
+		[]any{"active", "=", true},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	const prefix = "dashboard:customer_statement:"
+	storedIDs := []string{}
+	iter := redisutil.RedisClient.Scan(ctx, 0, prefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		storedIDs = append(storedIDs, strings.TrimPrefix(iter.Val(), prefix))
+	}
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+
+	stale := staleIDs(storedIDs, liveIDs)
+	for _, id := range stale {
+		redisutil.RedisClient.Del(ctx, prefix+id)
+	}
+	return len(stale), nil
+}
+
+// reconcilePricelists purges pricelist:* hashes whose item was deleted or whose pricelist was archived
+func reconcilePricelists(ctx context.Context) (int, error) {
+	liveKeys := make(map[string]bool)
+	offset, limit := 0, 1000
+	for {
+		batch, err := fetchPricelists(ctx, offset, limit)
+		if err != nil {
+			return 0, err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		for _, item := range batch {
+			redisKey, _, err := processPricelistItem(item)
+			if err == nil {
+				liveKeys[redisKey] = true
+			}
+		}
+		offset += limit
+	}
+
+	// Refuse to wipe everything if Odoo returned nothing
+	if len(liveKeys) == 0 {
+		return 0, nil
+	}
+
+	stale := []string{}
+	iter := redisutil.RedisClient.Scan(ctx, 0, "pricelist:*:category:*", 1000).Iterator()
+	for iter.Next(ctx) {
+		if !liveKeys[iter.Val()] {
+			stale = append(stale, iter.Val())
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < len(stale); i += 1000 {
+		end := min(i+1000, len(stale))
+		if err := redisutil.RedisClient.Del(ctx, stale[i:end]...).Err(); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
+
+// reconcileOrders purges orders that were deleted or are older than the sync window
+func reconcileOrders(ctx context.Context) (int, error) {
+	windowStart := time.Now().UTC().AddDate(0, 0, -reconcileWindowDays)
+	liveIDs, err := odooSearchIDs(ctx, "sale.order", []any{
+		[]any{"date_order", ">", windowStart.Format(odooDatetimeLayout)},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	storedIDs, err := orderPages.ids(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	stale := staleIDs(storedIDs, liveIDs)
+	if err := orderPages.remove(ctx, stale); err != nil {
+		return 0, err
+	}
+	if err := removeOrderLines(ctx, stale); err != nil {
+		return 0, err
+	}
+	if err := deleteTypesenseDocuments(ctx, "orders", stale); err != nil {
+		return 0, err
+	}
+
+	// Orders indexed before the page index existed are only in Typesense
+	filterBy := fmt.Sprintf("date_order_ts:<%d", windowStart.Unix())
+	aged, err := typesenseutil.TypesenseClient.Collection("orders").Documents().Delete(ctx, &api.DeleteDocumentsParams{
+		FilterBy: &filterBy,
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(stale) + aged, nil
+}
+
+// reconcileInvoices purges invoices that were deleted, are no longer posted or are older than the sync window
+func reconcileInvoices(ctx context.Context) (int, error) {
+	windowStart := time.Now().UTC().AddDate(0, 0, -reconcileWindowDays)
+	liveIDs, err := odooSearchIDs(ctx, "account.move", []any{
+		invoiceTypeDomain(),
+		[]any{"state", "=", "posted"},
+		[]any{"invoice_date", ">", windowStart.Format("2006-01-02")},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	storedRaw, _ := redisutil.RedisClient.Get(ctx, invoiceIDsKey).Result()
+	var stored []int
+	if storedRaw != "" {
+		if err := json.Unmarshal([]byte(storedRaw), &stored); err != nil {
+			return 0, err
+		}
+	}
+
+	storedIDs := make([]string, len(stored))
+	for i, id := range stored {
+		storedIDs[i] = strconv.Itoa(id)
+	}
+
+	stale := staleIDs(storedIDs, liveIDs)
+	if len(stale) == 0 {
+		return 0, nil
+	}
+
+	staleInts := make([]int, 0, len(stale))
+	for _, id := range stale {
+		if n, err := strconv.Atoi(id); err == nil {
+			staleInts = append(staleInts, n)
+		}
+	}
+	removeInvoices(ctx, staleInts)
+
+	// Only drop the stale ids, so invoices another sync added meanwhile stay listed
+	if _, err := mergeInvoiceIDs(ctx, nil, staleInts); err != nil {
+		return 0, err
+	}
+	return len(stale), nil
+}
+
+// odooSearchIDs returns every id matching the domain. Odoo's search skips
+// archived records unless the domain asks for them.
+func odooSearchIDs(ctx context.Context, model string, domain []any) (map[string]bool, error) {
+	payload := map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "call",
+		"params": map[string]any{
+			"model":  model,
+			"method": "search",
+			"args":   []any{domain},
+			"kwargs": map[string]any{},
+		},
+		"id": 2,
+	}
+
+	resp, err := odooRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result []int `json:"result"`
+		Error  any   `json:"error"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, err
+	}
+	// An error with an empty result would otherwise look like "everything was deleted"
+	if rpcResp.Error != nil {
+		return nil, fmt.Errorf("odoo search on %s failed: %v", model, rpcResp.Error)
+	}
+
+	ids := make(map[string]bool, len(rpcResp.Result))
+	for _, id := range rpcResp.Result {
+		ids[strconv.Itoa(id)] = true
+	}
+	return ids, nil
+}
+
+// staleIDs returns the stored ids that are not live.
+// An empty live set is treated as a failed read rather than "everything was deleted".
+func staleIDs(stored []string, live map[string]bool) []string {
+	stale := []string{}
+	if len(live) == 0 {
+		return stale
+	}
+	for _, id := range stored {
+		if !live[id] {
+			stale = append(stale, id)
+		}
+	}
+	return stale
+}
+
+// deleteTypesenseDocuments deletes documents by id in chunks
+func deleteTypesenseDocuments(ctx context.Context, collection string, ids []string) error {
+	for i := 0; i < len(ids); i += 500 {
+		end := min(i+500, len(ids))
+		filterBy := fmt.Sprintf("id:[%s]", strings.Join(ids[i:end], ","))
+		if _, err := typesenseutil.TypesenseClient.Collection(collection).Documents().Delete(ctx, &api.DeleteDocumentsParams{
+			FilterBy: &filterBy,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,284 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+// HandleSyncRecordsTask resyncs the records pushed by the Odoo webhook. Each id
+// is read back with the entity's usual domain; ids that no longer match were
+// deleted, archived or fell out of the window and are removed instead.
+func HandleSyncRecordsTask(ctx context.Context, t *asynq.Task) error {
+	var payload tasks.RecordSyncPayload
+	if err := json.Unmarshal(t.Payload(), &payload); err != nil {
+		return fmt.Errorf("invalid sync:records payload: %v: %w", err, asynq.SkipRetry)
+	}
+
+	taskID, _ := asynq.GetTaskID(ctx)
+	ids, err := tasks.ClaimPendingRecords(ctx, payload.Model, taskID)
+	if err != nil {
+		log.Printf("❌ Failed to claim pending %s ids: %v", payload.Model, err)
+		return err
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	log.Printf("🎯 Targeted sync of %d %s records", len(ids), payload.Model)
+
+	switch payload.Model {
+	case "product.product":
+		err = syncProductRecords(ctx, []any{[]any{"id", "in", ids}}, ids)
+	case "product.template":
+		// Template changes touch every variant; removals are left to reconcile
+		err = syncProductRecords(ctx, []any{[]any{"product_tmpl_id", "in", ids}}, nil)
+	case "res.partner":
+		err = syncCustomerRecords(ctx, ids)
+	case "product.pricelist.item":
+		err = syncPricelistRecords(ctx, ids)
+	case "sale.order":
+		err = syncOrderRecords(ctx, ids)
+	case "account.move":
+		err = syncInvoiceRecords(ctx, ids)
+	default:
+		return fmt.Errorf("model %q can't be synced by id: %w", payload.Model, asynq.SkipRetry)
+	}
+	if err != nil {
+		log.Printf("❌ Targeted sync of %s failed: %v", payload.Model, err)
+		return err
+	}
+
+	if err := tasks.ReleaseClaimedRecords(ctx, payload.Model, taskID); err != nil {
+		log.Printf("⚠️  Failed to release claimed %s ids: %v", payload.Model, err)
+	}
+	log.Printf("✅ Targeted sync of %s completed", payload.Model)
+	return nil
+}
+
+// missingIDs returns the requested ids Odoo no longer returned
+func missingIDs(requested []int, found []map[string]any) []string {
+	seen := make(map[int]bool, len(found))
+	for _, record := range found {
+		seen[getInt(record["id"])] = true
+	}
+	missing := []string{}
+	for _, id := range requested {
+		if !seen[id] {
+			missing = append(missing, strconv.Itoa(id))
+		}
+	}
+	return missing
+}
+
+func toDocuments(records []map[string]any) []any {
+	documents := make([]any, len(records))
+	for i, record := range records {
+		documents[i] = record
+	}
+	return documents
+}
+
+// syncProductRecords refreshes the products matching the filter. When requested
+// is set, requested ids that are no longer saleable are removed.
+func syncProductRecords(ctx context.Context, filter []any, requested []int) error {
+	stats := runStatsFrom(ctx)
+	products, err := odooSearchRead(ctx, "product.product", append(productDomain(), filter...), productFields, 1000)
+	if err != nil {
+		return err
+	}
+
+	lookups, err := fetchProductLookups(ctx)
+	if err != nil {
+		return err
+	}
+
+	cleaned := []map[string]any{}
+	removed := []string{}
+	for _, product := range products {
+		if skipProduct(product) {
+			removed = append(removed, strconv.Itoa(getInt(product["id"])))
+			stats.skipped(1)
+			continue
+		}
+		cleaned = append(cleaned, lookups.clean(product))
+	}
+	stats.processed(len(cleaned))
+	if requested != nil {
+		removed = append(removed, missingIDs(requested, products)...)
+	}
+
+	if err := productPages.upsert(ctx, cleaned); err != nil {
+		return err
+	}
+	if err := productPages.remove(ctx, removed); err != nil {
+		return err
+	}
+	if total, err := productPages.size(ctx); err == nil {
+		redisutil.RedisClient.Set(ctx, "products:total", total, 0)
+		stats.items(total)
+	}
+
+	if len(cleaned) > 0 {
+		report, err := typesenseutil.ImportDocuments(ctx, "products", currentRunID(ctx), toDocuments(cleaned), api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			return err
+		}
+	}
+	return deleteTypesenseDocuments(ctx, "products", removed)
+}
+
+func syncCustomerRecords(ctx context.Context, ids []int) error {
+	stats := runStatsFrom(ctx)
+	customers, err := odooSearchRead(ctx, "res.partner", []any{
+		[]any{"customer_rank", ">", 0},
+		[]any{"id", "in", ids},
+	}, customerFields, 1000)
+	if err != nil {
+		return err
+	}
+
+	cleaned := make([]map[string]any, 0, len(customers))
+	for _, customer := range customers {
+		customerDoc := cleanCustomer(customer)
+		saveCustomerHash(ctx, customerDoc)
+		cleaned = append(cleaned, customerDoc)
+	}
+	stats.processed(len(cleaned))
+
+	if err := customerPages.upsert(ctx, cleaned); err != nil {
+		return err
+	}
+	if err := removeCustomers(ctx, missingIDs(ids, customers)); err != nil {
+		return err
+	}
+
+	if len(cleaned) > 0 {
+		report, err := typesenseutil.ImportDocuments(ctx, "customers", currentRunID(ctx), toDocuments(cleaned), api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncPricelistRecords rewrites the given pricelist items. A deleted item's key
+// can't be derived from its id, so deletions are left to the nightly reconcile.
+func syncPricelistRecords(ctx context.Context, ids []int) error {
+	stats := runStatsFrom(ctx)
+	items, err := odooSearchRead(ctx, "product.pricelist.item", []any{
+		[]any{"id", "in", ids},
+	}, []string{
+		"pricelist_id", "categ_id", "product_tmpl_id",
+		"base_pricelist_id", "applied_on", "base",
+		"price_discount", "percent_price",
+	}, 1000)
+	if err != nil {
+		return err
+	}
+
+	pipe := redisutil.RedisClient.Pipeline()
+	for _, item := range items {
+		redisKey, mapping, err := processPricelistItem(item)
+		if err != nil {
+			stats.skipped(1)
+			continue
+		}
+		hsetMap := make(map[string]string)
+		for k, v := range mapping {
+			hsetMap[k] = fmt.Sprintf("%v", v)
+		}
+		pipe.HSet(ctx, redisKey, hsetMap)
+		stats.processed(1)
+	}
+	_, err = pipe.Exec(ctx)
+	return err
+}
+
+func syncOrderRecords(ctx context.Context, ids []int) error {
+	stats := runStatsFrom(ctx)
+	orders, err := odooSearchRead(ctx, "sale.order", append(orderDomain(), []any{"id", "in", ids}), orderFields, 1000)
+	if err != nil {
+		return err
+	}
+
+	cleaned := make([]map[string]any, 0, len(orders))
+	for _, order := range orders {
+		cleaned = append(cleaned, cleanOrder(order))
+	}
+	stats.processed(len(cleaned))
+	removed := missingIDs(ids, orders)
+
+	if err := orderPages.upsert(ctx, cleaned); err != nil {
+		return err
+	}
+	if err := orderPages.remove(ctx, removed); err != nil {
+		return err
+	}
+	if err := syncOrderLines(ctx, orderIDs(cleaned)); err != nil {
+		return err
+	}
+	if err := removeOrderLines(ctx, removed); err != nil {
+		return err
+	}
+
+	if len(cleaned) > 0 {
+		report, err := typesenseutil.ImportDocuments(ctx, "orders", currentRunID(ctx), toDocuments(cleaned), api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			return err
+		}
+	}
+	return deleteTypesenseDocuments(ctx, "orders", removed)
+}
+
+func syncInvoiceRecords(ctx context.Context, ids []int) error {
+	stats := runStatsFrom(ctx)
+	invoices, err := odooSearchRead(ctx, "account.move", []any{
+		invoiceTypeDomain(),
+		[]any{"state", "=", "posted"},
+		[]any{"id", "in", ids},
+	}, invoiceFields, 1000)
+	if err != nil {
+		return err
+	}
+
+	cleaned := make([]map[string]any, 0, len(invoices))
+	kept := make([]int, 0, len(invoices))
+	for _, inv := range invoices {
+		invoiceDoc, _ := cleanInvoice(inv)
+		saveInvoiceHeader(ctx, invoiceDoc)
+		cleaned = append(cleaned, invoiceDoc)
+		kept = append(kept, getInt(inv["id"]))
+	}
+	stats.processed(len(cleaned))
+
+	removed := []int{}
+	for _, id := range missingIDs(ids, invoices) {
+		n, _ := strconv.Atoi(id)
+		removed = append(removed, n)
+	}
+	removeInvoices(ctx, removed)
+	if _, err := mergeInvoiceIDs(ctx, kept, removed); err != nil {
+		return err
+	}
+	syncInvoiceLines(ctx, kept)
+
+	if len(cleaned) > 0 {
+		report, err := typesenseutil.ImportDocuments(ctx, "invoices", currentRunID(ctx), toDocuments(cleaned), api.IndexAction("upsert"))
+		stats.imported(report)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	gosync "sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/odoo"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+type runStatsKey struct{}
+
+// runStats collects the counters of one sync task attempt. All methods are
+// nil-safe so handlers can report unconditionally, even outside the worker.
+type runStats struct {
+	mu          gosync.Mutex
+	record      tasks.RunRecord
+	redisWrites atomic.Int64
+}
+
+// RecordRuns is asynq middleware that writes a run record for every sync:* task attempt
+func RecordRuns(next asynq.Handler) asynq.Handler {
+	return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) error {
+		if !strings.HasPrefix(task.Type(), "sync:") {
+			return next.ProcessTask(ctx, task)
+		}
+
+		taskID, _ := asynq.GetTaskID(ctx)
+		attempt, _ := asynq.GetRetryCount(ctx)
+		if taskID == "" {
+			taskID = fmt.Sprintf("manual-%d", time.Now().UnixNano())
+		}
+
+		stats := &runStats{record: tasks.RunRecord{
+			RunID:     fmt.Sprintf("%s-%d", taskID, attempt),
+			TaskID:    taskID,
+			Task:      task.Type(),
+			Entity:    strings.TrimPrefix(task.Type(), "sync:"),
+			Attempt:   attempt,
+			Status:    tasks.RunRunning,
+			StartedAt: time.Now().UTC(),
+		}}
+		if ref := tasks.WorkflowRefFromPayload(task.Payload()); ref != nil {
+			stats.record.WorkflowRunID = ref.RunID
+		}
+		// Bookkeeping uses the outer context so it doesn't count towards the run's writes
+		saveRun(ctx, stats.snapshot())
+
+		runCtx := context.WithValue(ctx, runStatsKey{}, stats)
+		runCtx = redisutil.WithWriteCounter(runCtx, &stats.redisWrites)
+		err := next.ProcessTask(runCtx, task)
+
+		stats.finish(err)
+		record := stats.snapshot()
+		// A timed-out or cancelled run must still leave its final record behind
+		saveRun(context.WithoutCancel(ctx), record)
+		recordRunMetrics(record)
+		log.Printf("📊 %s run %s %s in %dms: %d pages, %d processed, %d skipped, %d Redis writes, %d/%d Typesense imported/failed, %d Odoo calls",
+			record.Task, record.RunID, record.Status, record.DurationMs, record.PagesFetched, record.RecordsProcessed,
+			record.RecordsSkipped, record.RedisKeysWritten, record.TypesenseImported, record.TypesenseFailed, record.OdooCalls)
+		return err
+	})
+}
+
+func runStatsFrom(ctx context.Context) *runStats {
+	stats, _ := ctx.Value(runStatsKey{}).(*runStats)
+	return stats
+}
+
+// currentRunID identifies the sync run a handler is part of: the run record ID
+// when running under the worker, a timestamp otherwise
+func currentRunID(ctx context.Context) string {
+	if stats := runStatsFrom(ctx); stats != nil {
+		return stats.record.RunID
+	}
+	if id, ok := asynq.GetTaskID(ctx); ok {
+		return id
+	}
+	return fmt.Sprintf("manual-%d", time.Now().UnixNano())
+}
+
+// page counts one page of records fetched from Odoo
+func (stats *runStats) page() {
+	stats.add(func(r *tasks.RunRecord) { r.PagesFetched++ })
+}
+
+func (stats *runStats) processed(n int) {
+	stats.add(func(r *tasks.RunRecord) { r.RecordsProcessed += int64(n) })
+}
+
+func (stats *runStats) skipped(n int) {
+	stats.add(func(r *tasks.RunRecord) { r.RecordsSkipped += int64(n) })
+}
+
+// items records how many records the entity holds after the run
+func (stats *runStats) items(n int64) {
+	stats.add(func(r *tasks.RunRecord) { r.Items = n })
+}
+
+func (stats *runStats) odooCall() {
+	stats.add(func(r *tasks.RunRecord) { r.OdooCalls++ })
+}
+
+func (stats *runStats) imported(report typesenseutil.ImportReport) {
+	stats.add(func(r *tasks.RunRecord) {
+		r.TypesenseImported += int64(report.Imported)
+		r.TypesenseFailed += int64(report.Failed)
+		r.Imports = append(r.Imports, report)
+	})
+}
+
+func (stats *runStats) add(update func(r *tasks.RunRecord)) {
+	if stats == nil {
+		return
+	}
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	update(&stats.record)
+}
+
+func (stats *runStats) finish(err error) {
+	stats.add(func(r *tasks.RunRecord) {
+		finished := time.Now().UTC()
+		r.FinishedAt = &finished
+		r.DurationMs = finished.Sub(r.StartedAt).Milliseconds()
+		r.Status = tasks.RunSucceeded
+		if err != nil {
+			r.Status = tasks.RunFailed
+			r.Error = err.Error()
+		}
+	})
+}
+
+func (stats *runStats) snapshot() tasks.RunRecord {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	record := stats.record
+	record.RedisKeysWritten = stats.redisWrites.Load()
+	record.Imports = append([]typesenseutil.ImportReport(nil), stats.record.Imports...)
+	return record
+}
+
+func recordRunMetrics(record tasks.RunRecord) {
+	metrics.SyncTaskDuration.WithLabelValues(record.Entity, record.Status).Observe(float64(record.DurationMs) / 1000)
+	metrics.SyncRecords.WithLabelValues(record.Entity, "processed").Add(float64(record.RecordsProcessed))
+	metrics.SyncRecords.WithLabelValues(record.Entity, "skipped").Add(float64(record.RecordsSkipped))
+	if record.Status == tasks.RunSucceeded && record.FinishedAt != nil {
+		metrics.LastSuccessfulSync.WithLabelValues(record.Entity).Set(float64(record.FinishedAt.Unix()))
+	}
+}
+
+// RestoreSyncMetrics seeds last_successful_sync_timestamp from the run history,
+// so a restarted worker doesn't report every entity as never synced
+func RestoreSyncMetrics(ctx context.Context) {
+	lastOK, err := redisutil.RedisClient.HGetAll(ctx, tasks.SyncRunsLastOKKey).Result()
+	if err != nil {
+		log.Printf("⚠️  Failed to load last successful syncs: %v", err)
+		return
+	}
+	for entity, runID := range lastOK {
+		data, err := redisutil.RedisClient.Get(ctx, tasks.SyncRunKey(runID)).Bytes()
+		if err != nil {
+			continue
+		}
+		var record tasks.RunRecord
+		if err := json.Unmarshal(data, &record); err == nil && record.FinishedAt != nil {
+			metrics.LastSuccessfulSync.WithLabelValues(entity).Set(float64(record.FinishedAt.Unix()))
+		}
+	}
+}
+
+// saveRun stores the record and indexes it, dropping index entries past retention
+func saveRun(ctx context.Context, record tasks.RunRecord) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return
+	}
+
+	retention := runRetention()
+	cutoff := strconv.FormatInt(time.Now().Add(-retention).Unix(), 10)
+	started := redis.Z{Score: float64(record.StartedAt.Unix()), Member: record.RunID}
+
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.Set(ctx, tasks.SyncRunKey(record.RunID), data, retention)
+	pipe.ZAdd(ctx, tasks.SyncRunsKey, started)
+	pipe.ZAdd(ctx, tasks.SyncEntityRunsKey(record.Entity), started)
+	pipe.ZRemRangeByScore(ctx, tasks.SyncRunsKey, "-inf", cutoff)
+	pipe.ZRemRangeByScore(ctx, tasks.SyncEntityRunsKey(record.Entity), "-inf", cutoff)
+	pipe.HSet(ctx, tasks.SyncRunsLatestKey, record.Entity, record.RunID)
+	if record.Status == tasks.RunSucceeded {
+		pipe.HSet(ctx, tasks.SyncRunsLastOKKey, record.Entity, record.RunID)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to save sync run %s: %v", record.RunID, err)
+	}
+}
+
+func runRetention() time.Duration {
+	days, err := strconv.Atoi(config.ConfigGlobal.SyncRunRetentionDays)
+	if err != nil || days <= 0 {
+		days = tasks.DefaultRunRetentionDays
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// odooRequest sends a JSON-RPC call_kw request with the service session and counts it against the run
+func odooRequest(ctx context.Context, payload map[string]any) (*http.Response, error) {
+	return runCaller{ctx}.NewRequest("POST", "web/dataset/call_kw", payload)
+}
+
+// runCaller is the service session as an odoo.Caller that counts calls against the run
+type runCaller struct {
+	ctx context.Context
+}
+
+func (caller runCaller) NewRequest(method, endpoint string, payload map[string]any) (*http.Response, error) {
+	runStatsFrom(caller.ctx).odooCall()
+	return odoo.OdooManager.NewRequest(method, endpoint, payload)
+}
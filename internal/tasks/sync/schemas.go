@@ -0,0 +1,46 @@
+package sync
+
+import (
+	"context"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/tasks"
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+)
+
+// MigrateTypesenseSchemas brings every live collection in line with the schemas
+// defined in this package. Called by the scheduler leader when it takes over,
+// so only one worker ever migrates. Reindexes go through the alias: the full
+// sync builds a new version from the current schema and switches to it.
+func MigrateTypesenseSchemas(ctx context.Context, client *asynq.Client) {
+	typesenseutil.MigrateSchemas(ctx, []typesenseutil.ManagedSchema{
+		{
+			// Aliased: the full sync builds a new version from productsSchema()
+			Schema:  productsSchema(),
+			Reindex: enqueueFullSync(client, tasks.SyncProducts),
+		},
+		{
+			Schema:  customersSchema(),
+			Reindex: enqueueFullSync(client, tasks.SyncCustomers),
+		},
+		{
+			Schema:  ordersSchema(),
+			Reindex: enqueueFullSync(client, tasks.SyncOrders),
+		},
+		{
+			Schema:  invoicesSchema(),
+			Reindex: enqueueFullSync(client, tasks.SyncInvoicesAndLines),
+		},
+		{
+			Schema:  paymentsSchema(),
+			Reindex: enqueueFullSync(client, tasks.SyncPayments),
+		},
+	})
+}
+
+func enqueueFullSync(client *asynq.Client, taskType string) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		_, err := client.EnqueueContext(ctx, tasks.FullSyncTask(taskType))
+		return err
+	}
+}
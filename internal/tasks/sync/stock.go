@@ -0,0 +1,128 @@
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	typesenseutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/typesense"
+	"github.com/hibiken/asynq"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+// HandleSyncStockTask refreshes only the stock fields of saleable products.
+// qty_available is computed in Odoo and doesn't move write_date, so every run
+// reads the four stock fields of all saleable products and writes only the
+// products whose levels changed, as a partial Typesense update and then to Redis.
+// Redis is the change baseline, so it only takes levels Typesense accepted:
+// a rejected update shows up as a change again on the next run.
+func HandleSyncStockTask(ctx context.Context, t *asynq.Task) error {
+	log.Println("🔄 Starting stock sync...")
+	stats := runStatsFrom(ctx)
+	startTime := time.Now()
+
+	levels := make(map[string]map[string]any)
+	offset, limit := 0, 2000
+	for {
+		batch, err := fetchStockLevels(ctx, offset, limit)
+		if err != nil {
+			log.Printf("❌ Failed to fetch stock levels: %v", err)
+			return err
+		}
+		if len(batch) == 0 {
+			break
+		}
+		stats.page()
+
+		for _, product := range batch {
+			// Same shape cleanProduct produces: qty_available is net of outgoing
+			outgoing := getInt(product["outgoing_qty"])
+			levels[fmt.Sprintf("%d", getInt(product["id"]))] = map[string]any{
+				"qty_available": getInt(product["qty_available"]) - outgoing,
+				"outgoing_qty":  outgoing,
+				"incoming_qty":  getInt(product["incoming_qty"]),
+			}
+		}
+		offset += limit
+	}
+
+	changed, err := productPages.changes(ctx, levels)
+	if err != nil {
+		log.Printf("❌ Failed to compare stock levels with Redis: %v", err)
+		return err
+	}
+	stats.processed(len(changed))
+	stats.skipped(len(levels) - len(changed))
+	stats.items(int64(len(levels)))
+
+	if len(changed) > 0 {
+		documents := make([]any, len(changed))
+		for i, id := range changed {
+			document := map[string]any{"id": id}
+			for field, value := range levels[id] {
+				document[field] = value
+			}
+			documents[i] = document
+		}
+
+		report, err := typesenseutil.ImportDocuments(ctx, "products", currentRunID(ctx), documents, api.IndexAction("update"))
+		stats.imported(report)
+		if err != nil {
+			log.Printf("❌ Failed to update stock levels in Typesense: %v", err)
+			return err
+		}
+
+		accepted := make(map[string]map[string]any, len(changed))
+		for _, id := range changed {
+			accepted[id] = levels[id]
+		}
+		for _, id := range report.FailedIDs {
+			delete(accepted, id)
+		}
+		if _, err := productPages.patch(ctx, accepted); err != nil {
+			log.Printf("❌ Failed to update stock levels in Redis: %v", err)
+			return err
+		}
+	}
+
+	log.Printf("✅ Stock sync complete — %d of %d products changed in %.2fs", len(changed), len(levels), time.Since(startTime).Seconds())
+	return nil
+}
+
+// fetchStockLevels reads the stock fields of saleable products, the same set the products sync keeps
+func fetchStockLevels(ctx context.Context, offset, limit int) ([]map[string]any, error) {
+	payload := map[string]any{
+		"jsonrpc": "2.0",
+		"method":  "call",
+		"params": map[string]any{
+			"model":  "product.product",
+			"method": "search_read",
+			"args":   []any{productDomain()},
+			"kwargs": map[string]any{
+				"fields": []string{"id", "qty_available", "outgoing_qty", "incoming_qty"},
+				"offset": offset,
+				"limit":  limit,
+				"order":  "id asc",
+			},
+		},
+		"id": 2,
+	}
+
+	resp, err := odooRequest(ctx, payload)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rpcResp struct {
+		Result []map[string]any `json:"result"`
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return nil, err
+	}
+
+	return rpcResp.Result, nil
+}
@@ -1,6 +1,11 @@
 package tasks
 
-import "github.com/hibiken/asynq"
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
 
 const (
 	SyncProducts           = "sync:products"
@@ -9,10 +14,35 @@ const (
 	SyncCustomerStatements = "sync:customer_statements"
 	SyncOrders             = "sync:orders"
 	SyncInvoicesAndLines   = "sync:invoices_and_lines"
+	SyncReconcile          = "sync:reconcile"
+	SyncStock              = "sync:stock"
+	SyncCustomerRefresh    = "sync:customer_refresh"
+	SyncPayments           = "sync:payments"
+	SyncDeliveries         = "sync:deliveries"
 	ReleaseSyncLock        = "sync:release_lock"
 	OrchestrateFullSync    = "sync:orchestrate_full"
 )
 
+// CriticalQueue is served ahead of default, for refreshes a rep is waiting on
+const CriticalQueue = "critical"
+
+// SchedulerLeaderKey is the lease held by the worker that runs the scheduler
+const SchedulerLeaderKey = "scheduler:leader"
+
+// SyncOptions is the optional payload of an entity sync task.
+// Full skips the write_date watermark and re-reads every record; Workflow is
+// set when the task runs as a step of a workflow.
+type SyncOptions struct {
+	Full     bool         `json:"full,omitempty"`
+	Workflow *WorkflowRef `json:"workflow,omitempty"`
+}
+
+// FullSyncTask builds a sync task of the given type that forces a full reconciliation run
+func FullSyncTask(taskType string) *asynq.Task {
+	payload, _ := json.Marshal(SyncOptions{Full: true})
+	return asynq.NewTask(taskType, payload, asynq.MaxRetry(3))
+}
+
 func SyncProductsTask() *asynq.Task {
 	return asynq.NewTask(SyncProducts, nil, asynq.MaxRetry(3))
 }
@@ -37,6 +67,33 @@ func SyncInvoicesAndLinesTask() *asynq.Task {
 	return asynq.NewTask(SyncInvoicesAndLines, nil, asynq.MaxRetry(3))
 }
 
+func SyncReconcileTask() *asynq.Task {
+	return asynq.NewTask(SyncReconcile, nil, asynq.MaxRetry(3))
+}
+
+func SyncStockTask() *asynq.Task {
+	return asynq.NewTask(SyncStock, nil, asynq.MaxRetry(1))
+}
+
+func SyncPaymentsTask() *asynq.Task {
+	return asynq.NewTask(SyncPayments, nil, asynq.MaxRetry(3))
+}
+
+func SyncDeliveriesTask() *asynq.Task {
+	return asynq.NewTask(SyncDeliveries, nil, asynq.MaxRetry(3))
+}
+
+// CustomerRefreshPayload is the payload of a sync:customer_refresh task
+type CustomerRefreshPayload struct {
+	PartnerID int `json:"partner_id"`
+}
+
+// CustomerRefreshTask refreshes one customer's record, statement and invoices
+func CustomerRefreshTask(partnerID int) *asynq.Task {
+	payload, _ := json.Marshal(CustomerRefreshPayload{PartnerID: partnerID})
+	return asynq.NewTask(SyncCustomerRefresh, payload, asynq.MaxRetry(1), asynq.Queue(CriticalQueue), asynq.Timeout(time.Minute))
+}
+
 func OrchestrateFullSyncTask() *asynq.Task {
 	return asynq.NewTask(OrchestrateFullSync, nil, asynq.MaxRetry(3))
 }
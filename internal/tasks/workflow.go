@@ -0,0 +1,489 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// A Workflow is a DAG of asynq tasks. Nothing waits on it: StartWorkflow
+// enqueues the root steps, and WorkflowMiddleware enqueues a step's dependents
+// when it succeeds and applies the failure policy once it has failed for good.
+// Run state lives in the workflow:{run_id} hash.
+
+// FailurePolicy decides what happens when a step fails after its last retry
+type FailurePolicy string
+
+const (
+	// FailAbort stops the workflow: nothing else is enqueued
+	FailAbort FailurePolicy = "abort"
+	// FailContinue skips the failed step's dependents, other branches keep going
+	FailContinue FailurePolicy = "continue"
+	// FailRetryGroup re-runs the failed step's group up to MaxGroupRetries times, then aborts
+	FailRetryGroup FailurePolicy = "retry_group"
+)
+
+const (
+	StepPending   = "pending"
+	StepEnqueued  = "enqueued"
+	StepSucceeded = "succeeded"
+	StepFailed    = "failed"
+	StepSkipped   = "skipped"
+)
+
+const (
+	WorkflowRunning   = "running"
+	WorkflowSucceeded = "succeeded"
+	WorkflowFailed    = "failed"
+	WorkflowAborted   = "aborted"
+)
+
+const workflowStateTTL = 7 * 24 * time.Hour
+
+// Step is one task of a workflow. Name is the asynq task type.
+type Step struct {
+	Name      string
+	Group     string
+	DependsOn []string
+	// OnFailure overrides the workflow policy for this step
+	OnFailure FailurePolicy
+	MaxRetry  int
+}
+
+type Workflow struct {
+	Name            string
+	Steps           []Step
+	OnFailure       FailurePolicy
+	MaxGroupRetries int
+	// LockKey, when set, is a lease held for the whole run: taken by StartWorkflow,
+	// renewed while steps execute and released when the run finishes. Steps get
+	// its fencing token in their context (see redisutil.WithFence).
+	LockKey string
+	LockTTL time.Duration
+	// OnFinish runs once, when every step reached a terminal state
+	OnFinish func(ctx context.Context, runID, status string)
+}
+
+// WorkflowRef travels in the task payload and ties a task to its run and step
+type WorkflowRef struct {
+	RunID    string `json:"run_id"`
+	Workflow string `json:"workflow"`
+	Step     string `json:"step"`
+	Fence    int64  `json:"fence,omitempty"`
+}
+
+var workflows = map[string]*Workflow{}
+
+// RegisterWorkflow validates a workflow definition and makes it available to
+// StartWorkflow and WorkflowMiddleware
+func RegisterWorkflow(wf *Workflow) error {
+	steps := make(map[string]*Step, len(wf.Steps))
+	for i := range wf.Steps {
+		steps[wf.Steps[i].Name] = &wf.Steps[i]
+	}
+	for _, step := range wf.Steps {
+		for _, dep := range step.DependsOn {
+			if steps[dep] == nil {
+				return fmt.Errorf("workflow %s: step %s depends on unknown step %s", wf.Name, step.Name, dep)
+			}
+		}
+	}
+
+	// Depth-first search for cycles
+	visiting, done := map[string]bool{}, map[string]bool{}
+	var visit func(name string) error
+	visit = func(name string) error {
+		if done[name] {
+			return nil
+		}
+		if visiting[name] {
+			return fmt.Errorf("workflow %s: dependency cycle through %s", wf.Name, name)
+		}
+		visiting[name] = true
+		for _, dep := range steps[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		done[name] = true
+		return nil
+	}
+	for _, step := range wf.Steps {
+		if err := visit(step.Name); err != nil {
+			return err
+		}
+	}
+
+	workflows[wf.Name] = wf
+	return nil
+}
+
+// WorkflowKey is the Redis hash holding the state of one workflow run
+func WorkflowKey(runID string) string {
+	return fmt.Sprintf("workflow:%s", runID)
+}
+
+// WorkflowRefFromPayload returns the workflow reference of a task payload, if any
+func WorkflowRefFromPayload(payload []byte) *WorkflowRef {
+	var opts SyncOptions
+	if len(payload) == 0 || json.Unmarshal(payload, &opts) != nil {
+		return nil
+	}
+	return opts.Workflow
+}
+
+// StartWorkflow creates a run and enqueues every step without dependencies
+func StartWorkflow(ctx context.Context, client *asynq.Client, name string) (string, error) {
+	wf, ok := workflows[name]
+	if !ok {
+		return "", fmt.Errorf("unknown workflow %s", name)
+	}
+
+	runID := fmt.Sprintf("%s-%d", name, time.Now().UnixNano())
+	key := WorkflowKey(runID)
+	state := map[string]any{
+		"workflow":   name,
+		"status":     WorkflowRunning,
+		"started_at": time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var lock *redisutil.Lock
+	if wf.LockKey != "" {
+		var err error
+		lock, err = redisutil.AcquireLock(ctx, wf.LockKey, wf.LockTTL)
+		if err != nil {
+			return "", err
+		}
+		state["lock_owner"] = lock.Owner()
+		state["fence"] = lock.Token()
+	}
+	for _, step := range wf.Steps {
+		state["step:"+step.Name] = StepPending
+	}
+
+	pipe := redisutil.RedisClient.TxPipeline()
+	pipe.HSet(ctx, key, state)
+	pipe.Expire(ctx, key, workflowStateTTL)
+	pipe.Set(ctx, fmt.Sprintf("workflow:%s:latest", name), runID, workflowStateTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		if lock != nil {
+			lock.Release(ctx)
+		}
+		return "", err
+	}
+	log.Printf("🧭 Workflow %s started (run %s)", name, runID)
+
+	for _, step := range wf.Steps {
+		if len(step.DependsOn) == 0 {
+			enqueueStep(ctx, client, wf, runID, step)
+		}
+	}
+	return runID, nil
+}
+
+// WorkflowMiddleware advances workflows as their tasks finish. Retried
+// attempts are ignored; only success or the final failure counts.
+func WorkflowMiddleware(client *asynq.Client) asynq.MiddlewareFunc {
+	return func(next asynq.Handler) asynq.Handler {
+		return asynq.HandlerFunc(func(ctx context.Context, task *asynq.Task) (err error) {
+			ref := WorkflowRefFromPayload(task.Payload())
+			if ref == nil {
+				return next.ProcessTask(ctx, task)
+			}
+			wf, ok := workflows[ref.Workflow]
+			if !ok {
+				log.Printf("⚠️  Task %s belongs to unknown workflow %s", task.Type(), ref.Workflow)
+				return next.ProcessTask(ctx, task)
+			}
+
+			if lock := workflowLock(ctx, wf, ref.RunID); lock != nil {
+				// Keep the run's lease alive while this step works, and let its
+				// writers refuse to commit once a newer run took over
+				lock.KeepAlive(ctx)
+				defer lock.Stop()
+				ctx = redisutil.WithFence(ctx, wf.LockKey, lock.Token())
+			}
+
+			defer func() {
+				// A panicking step must still count as a failed attempt
+				if r := recover(); r != nil {
+					err = fmt.Errorf("panic in %s: %v", task.Type(), r)
+				}
+
+				taskID, _ := asynq.GetTaskID(ctx)
+				switch {
+				case err == nil:
+					stepSucceeded(context.WithoutCancel(ctx), client, wf, ref, taskID)
+				case isFinalAttempt(ctx, err):
+					stepFailed(context.WithoutCancel(ctx), client, wf, ref, taskID, err)
+				}
+			}()
+			return next.ProcessTask(ctx, task)
+		})
+	}
+}
+
+func isFinalAttempt(ctx context.Context, err error) bool {
+	if errors.Is(err, asynq.SkipRetry) {
+		return true
+	}
+	retried, _ := asynq.GetRetryCount(ctx)
+	maxRetry, _ := asynq.GetMaxRetry(ctx)
+	return retried >= maxRetry
+}
+
+// transitionScript moves a field from one state to another, failing if it is
+// no longer in the expected state (another worker got there first)
+var transitionScript = redis.NewScript(`
+if redis.call("HGET", KEYS[1], ARGV[1]) == ARGV[2] then
+	redis.call("HSET", KEYS[1], ARGV[1], ARGV[3])
+	return 1
+end
+return 0`)
+
+func transition(ctx context.Context, runID, step, from, to string) bool {
+	moved, err := transitionScript.Run(ctx, redisutil.RedisClient, []string{WorkflowKey(runID)}, "step:"+step, from, to).Int()
+	if err != nil {
+		log.Printf("⚠️  Workflow %s: failed to move %s %s -> %s: %v", runID, step, from, to, err)
+		return false
+	}
+	return moved == 1
+}
+
+// enqueueStep claims a pending step and enqueues its task. The task ID carries
+// the attempt, so completions of an older attempt are recognised and ignored.
+func enqueueStep(ctx context.Context, client *asynq.Client, wf *Workflow, runID string, step Step) {
+	key := WorkflowKey(runID)
+	if status, _ := redisutil.RedisClient.HGet(ctx, key, "status").Result(); status != WorkflowRunning {
+		transition(ctx, runID, step.Name, StepPending, StepSkipped)
+		return
+	}
+	if !transition(ctx, runID, step.Name, StepPending, StepEnqueued) {
+		return
+	}
+
+	attempt, err := redisutil.RedisClient.HIncrBy(ctx, key, "attempts:"+step.Name, 1).Result()
+	if err != nil {
+		log.Printf("❌ Workflow %s: failed to count attempt of %s: %v", runID, step.Name, err)
+	}
+	taskID := fmt.Sprintf("%s:%s:%d", runID, step.Name, attempt)
+	redisutil.RedisClient.HSet(ctx, key, "task:"+step.Name, taskID)
+
+	ref := &WorkflowRef{RunID: runID, Workflow: wf.Name, Step: step.Name}
+	if lock := workflowLock(ctx, wf, runID); lock != nil {
+		// The step may wait in the queue for a while, give it a full lease
+		if err := lock.Renew(ctx); err != nil {
+			log.Printf("⚠️  Workflow %s: %v", runID, err)
+		}
+		ref.Fence = lock.Token()
+	}
+	payload, _ := json.Marshal(SyncOptions{Workflow: ref})
+	maxRetry := step.MaxRetry
+	if maxRetry == 0 {
+		maxRetry = 3
+	}
+	if _, err := client.Enqueue(asynq.NewTask(step.Name, payload, asynq.MaxRetry(maxRetry), asynq.TaskID(taskID))); err != nil {
+		log.Printf("❌ Workflow %s: failed to enqueue %s: %v", runID, step.Name, err)
+		stepFailed(ctx, client, wf, &WorkflowRef{RunID: runID, Workflow: wf.Name, Step: step.Name}, taskID, err)
+		return
+	}
+	log.Printf("📤 Workflow %s: enqueued %s", runID, step.Name)
+}
+
+// currentAttempt reports whether taskID is the live attempt of the step
+func currentAttempt(ctx context.Context, ref *WorkflowRef, taskID string) bool {
+	current, err := redisutil.RedisClient.HGet(ctx, WorkflowKey(ref.RunID), "task:"+ref.Step).Result()
+	return err == nil && current == taskID
+}
+
+func stepSucceeded(ctx context.Context, client *asynq.Client, wf *Workflow, ref *WorkflowRef, taskID string) {
+	if !currentAttempt(ctx, ref, taskID) || !transition(ctx, ref.RunID, ref.Step, StepEnqueued, StepSucceeded) {
+		return
+	}
+	log.Printf("✅ Workflow %s: %s succeeded", ref.RunID, ref.Step)
+
+	state, err := redisutil.RedisClient.HGetAll(ctx, WorkflowKey(ref.RunID)).Result()
+	if err != nil {
+		log.Printf("❌ Workflow %s: failed to read state: %v", ref.RunID, err)
+		return
+	}
+	for _, step := range wf.Steps {
+		if !dependsOn(step, ref.Step) {
+			continue
+		}
+		if dependenciesSucceeded(step, state) {
+			enqueueStep(ctx, client, wf, ref.RunID, step)
+		}
+	}
+	finishIfDone(ctx, wf, ref.RunID)
+}
+
+func stepFailed(ctx context.Context, client *asynq.Client, wf *Workflow, ref *WorkflowRef, taskID string, cause error) {
+	if !currentAttempt(ctx, ref, taskID) || !transition(ctx, ref.RunID, ref.Step, StepEnqueued, StepFailed) {
+		return
+	}
+	key := WorkflowKey(ref.RunID)
+	redisutil.RedisClient.HSet(ctx, key, "error:"+ref.Step, cause.Error())
+
+	failed := findStep(wf, ref.Step)
+	policy := failed.OnFailure
+	if policy == "" {
+		policy = wf.OnFailure
+	}
+	log.Printf("❌ Workflow %s: %s failed (%s): %v", ref.RunID, ref.Step, policy, cause)
+
+	switch policy {
+	case FailRetryGroup:
+		retries, _ := redisutil.RedisClient.HIncrBy(ctx, key, "group_retries:"+failed.Group, 1).Result()
+		if int(retries) <= wf.MaxGroupRetries {
+			log.Printf("🔁 Workflow %s: retrying group %q (%d/%d)", ref.RunID, failed.Group, retries, wf.MaxGroupRetries)
+			// Members still running finish on their own; the rest go back to pending
+			reset := []Step{}
+			for _, step := range wf.Steps {
+				if step.Group != failed.Group {
+					continue
+				}
+				if transition(ctx, ref.RunID, step.Name, StepFailed, StepPending) ||
+					transition(ctx, ref.RunID, step.Name, StepSucceeded, StepPending) {
+					reset = append(reset, step)
+				}
+			}
+			// Only members whose dependencies hold go now; stepSucceeded releases
+			// the others as their dependencies finish again, keeping the DAG order
+			state, err := redisutil.RedisClient.HGetAll(ctx, key).Result()
+			if err != nil {
+				log.Printf("❌ Workflow %s: failed to read state: %v", ref.RunID, err)
+				return
+			}
+			for _, step := range reset {
+				if dependenciesSucceeded(step, state) {
+					enqueueStep(ctx, client, wf, ref.RunID, step)
+				}
+			}
+			return
+		}
+		abortWorkflow(ctx, wf, ref.RunID)
+	case FailContinue:
+		skipDependents(ctx, wf, ref.RunID, ref.Step)
+	default:
+		abortWorkflow(ctx, wf, ref.RunID)
+	}
+	finishIfDone(ctx, wf, ref.RunID)
+}
+
+// abortWorkflow stops new enqueues; steps already running finish on their own
+func abortWorkflow(ctx context.Context, wf *Workflow, runID string) {
+	redisutil.RedisClient.HSet(ctx, WorkflowKey(runID), "status", WorkflowAborted)
+	for _, step := range wf.Steps {
+		transition(ctx, runID, step.Name, StepPending, StepSkipped)
+	}
+	log.Printf("🛑 Workflow %s aborted", runID)
+}
+
+// skipDependents marks everything downstream of a failed step as skipped
+func skipDependents(ctx context.Context, wf *Workflow, runID, failed string) {
+	for _, step := range wf.Steps {
+		if dependsOn(step, failed) && transition(ctx, runID, step.Name, StepPending, StepSkipped) {
+			log.Printf("⏭️  Workflow %s: skipping %s", runID, step.Name)
+			skipDependents(ctx, wf, runID, step.Name)
+		}
+	}
+}
+
+// finishIfDone closes the run once every step is terminal, exactly once
+func finishIfDone(ctx context.Context, wf *Workflow, runID string) {
+	key := WorkflowKey(runID)
+	state, err := redisutil.RedisClient.HGetAll(ctx, key).Result()
+	if err != nil {
+		return
+	}
+
+	allSucceeded := true
+	for _, step := range wf.Steps {
+		switch state["step:"+step.Name] {
+		case StepPending, StepEnqueued:
+			return
+		case StepFailed, StepSkipped:
+			allSucceeded = false
+		}
+	}
+
+	claimed, err := redisutil.RedisClient.HSetNX(ctx, key, "finished_at", time.Now().UTC().Format(time.RFC3339)).Result()
+	if err != nil || !claimed {
+		return
+	}
+
+	status := WorkflowSucceeded
+	switch {
+	case state["status"] == WorkflowAborted:
+		status = WorkflowAborted
+	case !allSucceeded:
+		status = WorkflowFailed
+	}
+	redisutil.RedisClient.HSet(ctx, key, "status", status)
+	log.Printf("🏁 Workflow %s finished: %s", runID, status)
+
+	if lock := workflowLock(ctx, wf, runID); lock != nil {
+		if err := lock.Release(ctx); err != nil {
+			log.Printf("⚠️  Workflow %s: failed to release %s: %v", runID, wf.LockKey, err)
+		}
+	}
+
+	if wf.OnFinish != nil {
+		wf.OnFinish(ctx, runID, status)
+	}
+}
+
+// workflowLock returns a handle to the lease of a run, or nil if the workflow runs without a lock
+func workflowLock(ctx context.Context, wf *Workflow, runID string) *redisutil.Lock {
+	if wf.LockKey == "" {
+		return nil
+	}
+	values, err := redisutil.RedisClient.HMGet(ctx, WorkflowKey(runID), "lock_owner", "fence").Result()
+	if err != nil {
+		return nil
+	}
+	owner, _ := values[0].(string)
+	if owner == "" {
+		return nil
+	}
+	raw, _ := values[1].(string)
+	token, _ := strconv.ParseInt(raw, 10, 64)
+	return redisutil.ResumeLock(wf.LockKey, owner, token, wf.LockTTL)
+}
+
+// dependenciesSucceeded reports whether every dependency of step has succeeded in the run state
+func dependenciesSucceeded(step Step, state map[string]string) bool {
+	for _, dep := range step.DependsOn {
+		if state["step:"+dep] != StepSucceeded {
+			return false
+		}
+	}
+	return true
+}
+
+func dependsOn(step Step, name string) bool {
+	for _, dep := range step.DependsOn {
+		if dep == name {
+			return true
+		}
+	}
+	return false
+}
+
+func findStep(wf *Workflow, name string) Step {
+	for _, step := range wf.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	return Step{Name: name}
+}
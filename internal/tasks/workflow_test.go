@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/config"
+)
+
+func TestRegisterWorkflow(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []Step
+		wantErr string
+	}{
+		{
+			name: "diamond",
+			steps: []Step{
+				{Name: "a"},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"a"}},
+				{Name: "d", DependsOn: []string{"b", "c"}},
+			},
+		},
+		{
+			name:    "unknown dependency",
+			steps:   []Step{{Name: "a", DependsOn: []string{"missing"}}},
+			wantErr: "depends on unknown step missing",
+		},
+		{
+			name:    "self dependency",
+			steps:   []Step{{Name: "a", DependsOn: []string{"a"}}},
+			wantErr: "dependency cycle",
+		},
+		{
+			name: "cycle through three steps",
+			steps: []Step{
+				{Name: "root"},
+				{Name: "a", DependsOn: []string{"root", "c"}},
+				{Name: "b", DependsOn: []string{"a"}},
+				{Name: "c", DependsOn: []string{"b"}},
+			},
+			wantErr: "dependency cycle",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wf := &Workflow{Name: "test_" + strings.ReplaceAll(tt.name, " ", "_"), Steps: tt.steps}
+			defer delete(workflows, wf.Name)
+
+			err := RegisterWorkflow(wf)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Fatalf("unexpected error: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Fatalf("error = %v, want %q", err, tt.wantErr)
+			}
+			if _, registered := workflows[wf.Name]; registered != (tt.wantErr == "") {
+				t.Errorf("registered = %v", registered)
+			}
+		})
+	}
+}
+
+func TestDependenciesSucceeded(t *testing.T) {
+	step := Step{Name: "d", DependsOn: []string{"b", "c"}}
+
+	tests := []struct {
+		name  string
+		state map[string]string
+		want  bool
+	}{
+		{"no dependency run yet", map[string]string{}, false},
+		{"one dependency still enqueued", map[string]string{"step:b": StepSucceeded, "step:c": StepEnqueued}, false},
+		{"one dependency reset for a group retry", map[string]string{"step:b": StepSucceeded, "step:c": StepPending}, false},
+		{"one dependency failed", map[string]string{"step:b": StepSucceeded, "step:c": StepFailed}, false},
+		{"one dependency skipped", map[string]string{"step:b": StepSkipped, "step:c": StepSucceeded}, false},
+		{"all dependencies succeeded", map[string]string{"step:b": StepSucceeded, "step:c": StepSucceeded}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := dependenciesSucceeded(step, tt.state); got != tt.want {
+				t.Errorf("dependenciesSucceeded = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if !dependenciesSucceeded(Step{Name: "root"}, map[string]string{}) {
+		t.Error("a step without dependencies must be ready")
+	}
+}
+
+func TestFullSyncWorkflowOrder(t *testing.T) {
+	if config.ConfigGlobal == nil {
+		config.ConfigGlobal = &config.Config{}
+	}
+	wf := FullSyncWorkflow()
+	wf.Name = "test_full_sync"
+	defer delete(workflows, wf.Name)
+	if err := RegisterWorkflow(wf); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		step, dependency string
+		want             bool
+	}{
+		{SyncOrders, SyncCustomers, true},
+		{SyncInvoicesAndLines, SyncCustomers, true},
+		{SyncPayments, SyncCustomers, true},
+		{SyncDeliveries, SyncOrders, true},
+		{SyncDeliveries, SyncCustomers, false},
+		{SyncProducts, SyncCustomers, false},
+	}
+	for _, tt := range tests {
+		if got := dependsOn(findStep(wf, tt.step), tt.dependency); got != tt.want {
+			t.Errorf("%s depends on %s = %v, want %v", tt.step, tt.dependency, got, tt.want)
+		}
+	}
+
+	// Unknown SYNC_FAILURE_POLICY values fall back to continue
+	if wf.OnFailure != FailContinue {
+		t.Errorf("OnFailure = %q, want %q", wf.OnFailure, FailContinue)
+	}
+}
@@ -0,0 +1,198 @@
+package typesenseutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/typesense/typesense-go/v4/typesense"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+// keepVersions is how many versioned collections stay behind an alias:
+// the live one plus the previous one for rollback
+const keepVersions = 2
+
+// minImportSuccessRatio is the share of documents that must import cleanly
+// before a new version is allowed to go live
+const minImportSuccessRatio = 0.99
+
+// ReindexWithAlias builds a new versioned collection (e.g. products_v1712345678),
+// imports the documents, validates the result and then atomically points the
+// alias at it. Searches keep hitting the old version until the switch, and a
+// failed build leaves the alias untouched.
+func ReindexWithAlias(ctx context.Context, alias, runID string, schema *api.CollectionSchema, documents []any) (string, ImportReport, error) {
+	report := ImportReport{Collection: alias, RunID: runID, Total: len(documents)}
+	if len(documents) == 0 {
+		return "", report, errors.New("refusing to reindex with no documents")
+	}
+
+	versioned := *schema
+	versioned.Name = fmt.Sprintf("%s_v%d", alias, time.Now().Unix())
+	if _, err := TypesenseClient.Collections().Create(ctx, &versioned); err != nil {
+		return "", report, fmt.Errorf("failed to create %s: %w", versioned.Name, err)
+	}
+	log.Printf("🆕 Building %s for alias %s", versioned.Name, alias)
+
+	report, err := importInto(ctx, versioned.Name, alias, runID, documents, api.IndexAction("upsert"))
+	if err != nil {
+		dropCollection(ctx, versioned.Name)
+		return "", report, fmt.Errorf("import into %s failed: %w", versioned.Name, err)
+	}
+
+	if err := validateVersion(ctx, versioned.Name, len(documents), report.Imported); err != nil {
+		dropCollection(ctx, versioned.Name)
+		return "", report, err
+	}
+
+	// A run that lost the sync lock must not flip the alias over a newer run's build
+	if err := redisutil.CheckFence(ctx); err != nil {
+		dropCollection(ctx, versioned.Name)
+		return "", report, err
+	}
+
+	if err := switchAlias(ctx, alias, versioned.Name); err != nil {
+		dropCollection(ctx, versioned.Name)
+		return "", report, err
+	}
+	log.Printf("🔀 Alias %s -> %s (%d documents)", alias, versioned.Name, report.Imported)
+
+	garbageCollectVersions(ctx, alias)
+	return versioned.Name, report, nil
+}
+
+// EnsureAliasedCollection makes sure the alias resolves to a collection,
+// creating an empty first version when nothing exists yet
+func EnsureAliasedCollection(ctx context.Context, alias string, schema *api.CollectionSchema) error {
+	if _, err := TypesenseClient.Alias(alias).Retrieve(ctx); err == nil {
+		return nil
+	}
+	// Legacy plain collection from before aliases; the next full reindex migrates it
+	if _, err := TypesenseClient.Collection(alias).Retrieve(ctx); err == nil {
+		return nil
+	}
+
+	versioned := *schema
+	versioned.Name = fmt.Sprintf("%s_v%d", alias, time.Now().Unix())
+	if _, err := TypesenseClient.Collections().Create(ctx, &versioned); err != nil {
+		return fmt.Errorf("failed to create %s: %w", versioned.Name, err)
+	}
+	if _, err := TypesenseClient.Aliases().Upsert(ctx, alias, &api.CollectionAliasSchema{CollectionName: versioned.Name}); err != nil {
+		return fmt.Errorf("failed to point alias %s at %s: %w", alias, versioned.Name, err)
+	}
+	log.Printf("✅ Created %s behind alias %s", versioned.Name, alias)
+	return nil
+}
+
+// RollbackAlias points the alias back at the previous version kept for rollback
+func RollbackAlias(ctx context.Context, alias string) (string, error) {
+	current, err := TypesenseClient.Alias(alias).Retrieve(ctx)
+	if err != nil {
+		return "", fmt.Errorf("alias %s not found: %w", alias, err)
+	}
+
+	versions, err := listVersions(ctx, alias)
+	if err != nil {
+		return "", err
+	}
+
+	for i := len(versions) - 1; i >= 0; i-- {
+		if versions[i] < current.CollectionName {
+			if _, err := TypesenseClient.Aliases().Upsert(ctx, alias, &api.CollectionAliasSchema{CollectionName: versions[i]}); err != nil {
+				return "", err
+			}
+			log.Printf("⏪ Alias %s rolled back %s -> %s", alias, current.CollectionName, versions[i])
+			return versions[i], nil
+		}
+	}
+	return "", fmt.Errorf("no previous version of %s to roll back to", alias)
+}
+
+// validateVersion checks the new collection holds what we imported before it goes live
+func validateVersion(ctx context.Context, name string, expected, succeeded int) error {
+	if float64(succeeded) < float64(expected)*minImportSuccessRatio {
+		return fmt.Errorf("%s: only %d of %d documents imported", name, succeeded, expected)
+	}
+
+	collection, err := TypesenseClient.Collection(name).Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve %s for validation: %w", name, err)
+	}
+	if collection.NumDocuments == nil || *collection.NumDocuments != int64(succeeded) {
+		return fmt.Errorf("%s: expected %d documents, collection reports %v", name, succeeded, collection.NumDocuments)
+	}
+	return nil
+}
+
+// switchAlias upserts the alias. The first time, a legacy plain collection with
+// the alias name has to go, because Typesense resolves collection names before aliases.
+func switchAlias(ctx context.Context, alias, collectionName string) error {
+	if _, err := TypesenseClient.Alias(alias).Retrieve(ctx); err != nil {
+		if _, err := TypesenseClient.Collection(alias).Retrieve(ctx); err == nil {
+			log.Printf("⚠️  Migrating legacy collection %s to an alias", alias)
+			if _, err := TypesenseClient.Collection(alias).Delete(ctx); err != nil {
+				return fmt.Errorf("failed to drop legacy collection %s: %w", alias, err)
+			}
+		}
+	}
+
+	if _, err := TypesenseClient.Aliases().Upsert(ctx, alias, &api.CollectionAliasSchema{CollectionName: collectionName}); err != nil {
+		return fmt.Errorf("failed to point alias %s at %s: %w", alias, collectionName, err)
+	}
+	return nil
+}
+
+// garbageCollectVersions drops all but the newest keepVersions versions, never the live one
+func garbageCollectVersions(ctx context.Context, alias string) {
+	versions, err := listVersions(ctx, alias)
+	if err != nil {
+		log.Printf("⚠️  Could not list versions of %s: %v", alias, err)
+		return
+	}
+
+	live := ""
+	if current, err := TypesenseClient.Alias(alias).Retrieve(ctx); err == nil {
+		live = current.CollectionName
+	}
+
+	for i := 0; i < len(versions)-keepVersions; i++ {
+		if versions[i] == live {
+			continue
+		}
+		dropCollection(ctx, versions[i])
+		log.Printf("🗑️  Dropped old version %s", versions[i])
+	}
+}
+
+// listVersions returns the versioned collections of an alias, oldest first
+func listVersions(ctx context.Context, alias string) ([]string, error) {
+	collections, err := TypesenseClient.Collections().Retrieve(ctx, &api.GetCollectionsParams{})
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := alias + "_v"
+	versions := []string{}
+	for _, collection := range collections {
+		if strings.HasPrefix(collection.Name, prefix) {
+			versions = append(versions, collection.Name)
+		}
+	}
+	// Same prefix and fixed-width unix timestamps, so lexical order is creation order
+	sort.Strings(versions)
+	return versions, nil
+}
+
+func dropCollection(ctx context.Context, name string) {
+	if _, err := TypesenseClient.Collection(name).Delete(ctx); err != nil {
+		var httpErr *typesense.HTTPError
+		if !errors.As(err, &httpErr) || httpErr.Status != 404 {
+			log.Printf("⚠️  Failed to drop collection %s: %v", name, err)
+		}
+	}
+}
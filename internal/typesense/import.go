@@ -0,0 +1,212 @@
+package typesenseutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PrathameshKalekar/field-sales-go-backend/internal/metrics"
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/redis/go-redis/v9"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+const (
+	// ImportFailureRunsKey is a sorted set of "{collection}:{run}" ids scored by time
+	ImportFailureRunsKey = "typesense:import_failures:runs"
+	importFailureTTL     = 7 * 24 * time.Hour
+)
+
+// ImportReport summarises one Import call after per-document inspection
+type ImportReport struct {
+	Collection string `json:"collection"`
+	RunID      string `json:"run_id"`
+	Total      int    `json:"total"`
+	Imported   int    `json:"imported"`
+	Recovered  int    `json:"recovered"`
+	Failed     int    `json:"failed"`
+	// FailedIDs are the documents still rejected after the retry
+	FailedIDs []string `json:"-"`
+}
+
+// ImportFailure is one document Typesense rejected, as stored in Redis
+type ImportFailure struct {
+	ID         string `json:"id"`
+	Error      string `json:"error"`
+	RetryError string `json:"retry_error,omitempty"`
+	Recovered  bool   `json:"recovered"`
+	Document   any    `json:"document"`
+	FailedAt   string `json:"failed_at"`
+}
+
+// ImportFailuresKey is the Redis list holding the failures of one collection and run
+func ImportFailuresKey(collection, runID string) string {
+	return fmt.Sprintf("typesense:import_failures:%s:%s", collection, runID)
+}
+
+// ImportDocuments imports documents and checks every per-document result.
+// Rejected documents are sanitised from the error message and retried once;
+// all failures are kept in Redis under ImportFailuresKey(collection, runID).
+func ImportDocuments(ctx context.Context, collection, runID string, documents []any, action api.IndexAction) (ImportReport, error) {
+	return importInto(ctx, collection, collection, runID, documents, action)
+}
+
+// importInto imports into target but reports failures under collection, so a
+// versioned build (products_v123) files its failures under the alias name
+func importInto(ctx context.Context, target, collection, runID string, documents []any, action api.IndexAction) (ImportReport, error) {
+	report := ImportReport{Collection: collection, RunID: runID, Total: len(documents)}
+	if len(documents) == 0 {
+		return report, nil
+	}
+
+	defer recordImportMetrics(&report)
+
+	start := time.Now()
+	results, err := TypesenseClient.Collection(target).Documents().Import(ctx, documents, &api.ImportDocumentsParams{
+		Action: &action,
+	})
+	metrics.TypesenseImportDuration.WithLabelValues(collection).Observe(time.Since(start).Seconds())
+	if err != nil {
+		report.Failed = report.Total
+		return report, err
+	}
+
+	failures := []ImportFailure{}
+	retryDocs := []any{}
+	for i, result := range results {
+		if result != nil && result.Success {
+			report.Imported++
+			continue
+		}
+		if i >= len(documents) {
+			break
+		}
+
+		message := "no result returned"
+		if result != nil {
+			message = result.Error
+		}
+		failures = append(failures, ImportFailure{
+			ID:       documentID(documents[i]),
+			Error:    message,
+			Document: documents[i],
+			FailedAt: time.Now().UTC().Format(time.RFC3339),
+		})
+		retryDocs = append(retryDocs, sanitizeDocument(documents[i], message))
+	}
+
+	if len(failures) == 0 {
+		return report, nil
+	}
+
+	// Retry once with the sanitised documents
+	retryResults, err := TypesenseClient.Collection(target).Documents().Import(ctx, retryDocs, &api.ImportDocumentsParams{
+		Action: &action,
+	})
+	for i := range failures {
+		switch {
+		case err != nil:
+			failures[i].RetryError = err.Error()
+		case i < len(retryResults) && retryResults[i] != nil && retryResults[i].Success:
+			failures[i].Recovered = true
+			report.Recovered++
+		case i < len(retryResults) && retryResults[i] != nil:
+			failures[i].RetryError = retryResults[i].Error
+		}
+		if !failures[i].Recovered {
+			report.FailedIDs = append(report.FailedIDs, failures[i].ID)
+		}
+	}
+	report.Imported += report.Recovered
+	report.Failed = len(failures) - report.Recovered
+
+	storeImportFailures(ctx, collection, runID, failures)
+	log.Printf("⚠️  %s import: %d rejected, %d recovered on retry, %d still failing (run %s)",
+		collection, len(failures), report.Recovered, report.Failed, runID)
+	return report, nil
+}
+
+func recordImportMetrics(report *ImportReport) {
+	metrics.TypesenseImportDocuments.WithLabelValues(report.Collection, "imported").Add(float64(report.Imported - report.Recovered))
+	metrics.TypesenseImportDocuments.WithLabelValues(report.Collection, "recovered").Add(float64(report.Recovered))
+	metrics.TypesenseImportDocuments.WithLabelValues(report.Collection, "failed").Add(float64(report.Failed))
+}
+
+func storeImportFailures(ctx context.Context, collection, runID string, failures []ImportFailure) {
+	key := ImportFailuresKey(collection, runID)
+	pipe := redisutil.RedisClient.Pipeline()
+	for _, failure := range failures {
+		entry, err := json.Marshal(failure)
+		if err != nil {
+			continue
+		}
+		pipe.RPush(ctx, key, entry)
+	}
+	pipe.Expire(ctx, key, importFailureTTL)
+	pipe.ZAdd(ctx, ImportFailureRunsKey, redis.Z{Score: float64(time.Now().Unix()), Member: collection + ":" + runID})
+	pipe.ZRemRangeByScore(ctx, ImportFailureRunsKey, "-inf", strconv.FormatInt(time.Now().Add(-importFailureTTL).Unix(), 10))
+	if _, err := pipe.Exec(ctx); err != nil {
+		log.Printf("⚠️  Failed to store %s import failures: %v", collection, err)
+	}
+}
+
+// fieldErrorPattern pulls the field name out of messages like
+// "Field `qty_available` must be an int32." or "Field `barcode` has been declared in the schema, but is not found in the document."
+var fieldErrorPattern = regexp.MustCompile("[Ff]ield `([^`]+)`")
+
+// sanitizeDocument coerces the field named in the error to the type Typesense asked for
+func sanitizeDocument(document any, message string) any {
+	doc, ok := document.(map[string]any)
+	if !ok {
+		return document
+	}
+	match := fieldErrorPattern.FindStringSubmatch(message)
+	if match == nil {
+		return document
+	}
+
+	field := match[1]
+	cleaned := make(map[string]any, len(doc))
+	for k, v := range doc {
+		cleaned[k] = v
+	}
+	value := cleaned[field]
+	text := strings.TrimSpace(fmt.Sprintf("%v", value))
+
+	switch {
+	case strings.Contains(message, "must be an int32"), strings.Contains(message, "must be an int64"):
+		f, _ := strconv.ParseFloat(text, 64)
+		cleaned[field] = int64(f)
+	case strings.Contains(message, "must be a float"):
+		f, _ := strconv.ParseFloat(text, 64)
+		cleaned[field] = f
+	case strings.Contains(message, "must be a bool"):
+		b, _ := strconv.ParseBool(text)
+		cleaned[field] = b
+	case strings.Contains(message, "must be an array"):
+		if value == nil {
+			cleaned[field] = []any{}
+		} else {
+			cleaned[field] = []any{value}
+		}
+	case strings.Contains(message, "must be a string"), strings.Contains(message, "not found in the document"):
+		if value == nil || value == false {
+			cleaned[field] = ""
+		} else {
+			cleaned[field] = text
+		}
+	}
+	return cleaned
+}
+
+func documentID(document any) string {
+	if doc, ok := document.(map[string]any); ok {
+		return fmt.Sprintf("%v", doc["id"])
+	}
+	return ""
+}
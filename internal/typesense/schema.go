@@ -0,0 +1,188 @@
+package typesenseutil
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	redisutil "github.com/PrathameshKalekar/field-sales-go-backend/internal/redis"
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+const migrationLogKey = "typesense:migrations"
+
+// ManagedSchema is one entry of the schema registry: the schema the code wants,
+// and how to rebuild the collection when the live one can't be changed in place
+type ManagedSchema struct {
+	Schema  *api.CollectionSchema
+	Reindex func(ctx context.Context) error
+}
+
+// schemaDiff is what separates a live collection from its desired schema
+type schemaDiff struct {
+	inPlace      []api.Field
+	changes      []string
+	needsReindex bool
+}
+
+// MigrateSchemas diffs every registered schema against the live collection.
+// Changes are additive: new fields are added as optional (Typesense refuses a
+// required field on a collection that already holds documents) and re-flagged
+// fields are updated in place; live fields the code no longer declares are
+// only logged. Type or default_sorting_field changes trigger a full reindex.
+// Run it from one worker only (the scheduler leader).
+func MigrateSchemas(ctx context.Context, registry []ManagedSchema) {
+	for _, managed := range registry {
+		if err := migrateSchema(ctx, managed); err != nil {
+			log.Printf("❌ Schema migration for %s failed: %v", managed.Schema.Name, err)
+		}
+	}
+}
+
+func migrateSchema(ctx context.Context, managed ManagedSchema) error {
+	name := managed.Schema.Name
+	collectionName := resolveCollection(ctx, name)
+
+	live, err := TypesenseClient.Collection(collectionName).Retrieve(ctx)
+	if err != nil {
+		// Nothing deployed yet, the sync creates it from the desired schema
+		log.Printf("ℹ️  %s not found, skipping schema migration", name)
+		return nil
+	}
+
+	diff := diffSchema(managed.Schema, live)
+	if len(diff.changes) == 0 {
+		log.Printf("✅ %s schema up to date", name)
+		return nil
+	}
+
+	if diff.needsReindex {
+		log.Printf("⚠️  %s schema changed incompatibly: %v - reindexing", name, diff.changes)
+		if err := managed.Reindex(ctx); err != nil {
+			return fmt.Errorf("reindex failed: %w", err)
+		}
+		recordMigration(ctx, name, collectionName, "reindex", diff.changes)
+		return nil
+	}
+
+	if _, err := TypesenseClient.Collection(collectionName).Update(ctx, &api.CollectionUpdateSchema{
+		Fields: diff.inPlace,
+	}); err != nil {
+		return fmt.Errorf("update failed: %w", err)
+	}
+	log.Printf("🛠️  %s schema migrated in place: %v", name, diff.changes)
+	recordMigration(ctx, name, collectionName, "update", diff.changes)
+	return nil
+}
+
+func diffSchema(desired *api.CollectionSchema, live *api.CollectionResponse) schemaDiff {
+	diff := schemaDiff{}
+
+	if stringOr(desired.DefaultSortingField, "") != stringOr(live.DefaultSortingField, "") {
+		diff.needsReindex = true
+		diff.changes = append(diff.changes, fmt.Sprintf("default_sorting_field %q -> %q",
+			stringOr(live.DefaultSortingField, ""), stringOr(desired.DefaultSortingField, "")))
+	}
+
+	liveFields := make(map[string]api.Field, len(live.Fields))
+	for _, field := range live.Fields {
+		liveFields[field.Name] = field
+	}
+
+	desiredNames := make(map[string]bool, len(desired.Fields))
+	for _, field := range desired.Fields {
+		// id is implicit in Typesense and never listed in the live schema
+		if field.Name == "id" {
+			continue
+		}
+		desiredNames[field.Name] = true
+
+		current, exists := liveFields[field.Name]
+		switch {
+		case !exists:
+			optional := true
+			field.Optional = &optional
+			diff.inPlace = append(diff.inPlace, field)
+			diff.changes = append(diff.changes, fmt.Sprintf("add %s (%s)", field.Name, field.Type))
+		case current.Type != field.Type:
+			diff.needsReindex = true
+			diff.changes = append(diff.changes, fmt.Sprintf("%s type %s -> %s", field.Name, current.Type, field.Type))
+		case !sameAttributes(current, field):
+			// Typesense re-flags a field by dropping and re-adding it in one update;
+			// a field that was optional stays optional, for documents without it
+			drop := true
+			optional := boolOr(current.Optional, false) || boolOr(field.Optional, false)
+			field.Optional = &optional
+			diff.inPlace = append(diff.inPlace, api.Field{Name: field.Name, Drop: &drop}, field)
+			diff.changes = append(diff.changes, fmt.Sprintf("reconfigure %s", field.Name))
+		}
+	}
+
+	// Never drop data: a field the code stopped declaring may still be read by
+	// an older backend, or come back. Removing it is a manual decision.
+	for _, field := range live.Fields {
+		if field.Name == "id" || desiredNames[field.Name] {
+			continue
+		}
+		log.Printf("ℹ️  %s has field %s that the code doesn't declare, leaving it", desired.Name, field.Name)
+	}
+
+	return diff
+}
+
+// sameAttributes compares the flags we set in code, filling in Typesense defaults.
+// A live field that is optional where the code wants it required counts as the
+// same, since fields added in place are always optional.
+func sameAttributes(live, desired api.Field) bool {
+	sortDefault := isNumericType(desired.Type)
+	return boolOr(live.Facet, false) == boolOr(desired.Facet, false) &&
+		boolOr(live.Sort, sortDefault) == boolOr(desired.Sort, sortDefault) &&
+		boolOr(live.Infix, false) == boolOr(desired.Infix, false) &&
+		(boolOr(live.Optional, false) || !boolOr(desired.Optional, false)) &&
+		boolOr(live.Index, true) == boolOr(desired.Index, true)
+}
+
+func isNumericType(fieldType string) bool {
+	switch fieldType {
+	case "int32", "int64", "float", "bool":
+		return true
+	}
+	return false
+}
+
+// resolveCollection follows an alias to its collection, or returns the name unchanged
+func resolveCollection(ctx context.Context, name string) string {
+	if alias, err := TypesenseClient.Alias(name).Retrieve(ctx); err == nil {
+		return alias.CollectionName
+	}
+	return name
+}
+
+// recordMigration logs an applied migration to Redis for later inspection
+func recordMigration(ctx context.Context, name, collectionName, action string, changes []string) {
+	entry, _ := json.Marshal(map[string]any{
+		"collection": name,
+		"version":    collectionName,
+		"action":     action,
+		"changes":    changes,
+		"applied_at": time.Now().UTC().Format(time.RFC3339),
+	})
+	redisutil.RedisClient.LPush(ctx, migrationLogKey, entry)
+	redisutil.RedisClient.LTrim(ctx, migrationLogKey, 0, 199)
+}
+
+func boolOr(value *bool, fallback bool) bool {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
+
+func stringOr(value *string, fallback string) string {
+	if value == nil {
+		return fallback
+	}
+	return *value
+}
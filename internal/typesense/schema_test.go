@@ -0,0 +1,149 @@
+package typesenseutil
+
+import (
+	"testing"
+
+	"github.com/typesense/typesense-go/v4/typesense/api"
+)
+
+func TestDiffSchema(t *testing.T) {
+	yes, no := true, false
+	sortField, otherSortField := "date_ts", "amount"
+
+	base := []api.Field{
+		{Name: "id", Type: "string"},
+		{Name: "name", Type: "string"},
+		{Name: "date_ts", Type: "int64", Sort: &yes},
+		{Name: "partner_id", Type: "int32", Facet: &yes},
+	}
+	with := func(fields ...api.Field) []api.Field {
+		return append(append([]api.Field{}, base...), fields...)
+	}
+	// The live schema never lists id
+	live := func(fields []api.Field, sorting *string) *api.CollectionResponse {
+		liveFields := []api.Field{}
+		for _, field := range fields {
+			if field.Name != "id" {
+				liveFields = append(liveFields, field)
+			}
+		}
+		return &api.CollectionResponse{Name: "orders", Fields: liveFields, DefaultSortingField: sorting}
+	}
+
+	tests := []struct {
+		name         string
+		desired      []api.Field
+		live         *api.CollectionResponse
+		changes      int
+		needsReindex bool
+		// inPlace lists the updates sent to Typesense, in order
+		inPlace []api.Field
+	}{
+		{
+			name:    "up to date",
+			desired: base,
+			live:    live(base, &sortField),
+		},
+		{
+			name:    "new field is added as optional",
+			desired: with(api.Field{Name: "memo", Type: "string"}),
+			live:    live(base, &sortField),
+			changes: 1,
+			inPlace: []api.Field{{Name: "memo", Type: "string", Optional: &yes}},
+		},
+		{
+			name:    "field the code no longer declares is left alone",
+			desired: base,
+			live:    live(with(api.Field{Name: "legacy", Type: "string"}), &sortField),
+		},
+		{
+			name:         "type change needs a reindex",
+			desired:      with(api.Field{Name: "amount", Type: "float"}),
+			live:         live(with(api.Field{Name: "amount", Type: "string"}), &sortField),
+			changes:      1,
+			needsReindex: true,
+		},
+		{
+			name:         "default sorting field change needs a reindex",
+			desired:      base,
+			live:         live(base, &otherSortField),
+			changes:      1,
+			needsReindex: true,
+		},
+		{
+			name:    "facet change is dropped and re-added",
+			desired: with(api.Field{Name: "state", Type: "string", Facet: &yes}),
+			live:    live(with(api.Field{Name: "state", Type: "string"}), &sortField),
+			changes: 1,
+			inPlace: []api.Field{
+				{Name: "state", Drop: &yes},
+				{Name: "state", Type: "string", Facet: &yes, Optional: &no},
+			},
+		},
+		{
+			name:    "re-added field keeps its live optional flag",
+			desired: with(api.Field{Name: "state", Type: "string", Facet: &yes}),
+			live:    live(with(api.Field{Name: "state", Type: "string", Optional: &yes}), &sortField),
+			changes: 1,
+			inPlace: []api.Field{
+				{Name: "state", Drop: &yes},
+				{Name: "state", Type: "string", Facet: &yes, Optional: &yes},
+			},
+		},
+		{
+			name:    "optional live field satisfies a required one",
+			desired: with(api.Field{Name: "memo", Type: "string"}),
+			live:    live(with(api.Field{Name: "memo", Type: "string", Optional: &yes}), &sortField),
+		},
+		{
+			name:    "required live field is relaxed to optional",
+			desired: with(api.Field{Name: "memo", Type: "string", Optional: &yes}),
+			live:    live(with(api.Field{Name: "memo", Type: "string"}), &sortField),
+			changes: 1,
+			inPlace: []api.Field{
+				{Name: "memo", Drop: &yes},
+				{Name: "memo", Type: "string", Optional: &yes},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := diffSchema(&api.CollectionSchema{Name: "orders", Fields: tt.desired, DefaultSortingField: &sortField}, tt.live)
+
+			if len(diff.changes) != tt.changes {
+				t.Errorf("changes = %v, want %d", diff.changes, tt.changes)
+			}
+			if diff.needsReindex != tt.needsReindex {
+				t.Errorf("needsReindex = %v, want %v", diff.needsReindex, tt.needsReindex)
+			}
+			if tt.needsReindex {
+				return
+			}
+			if len(diff.inPlace) != len(tt.inPlace) {
+				t.Fatalf("inPlace = %+v, want %+v", diff.inPlace, tt.inPlace)
+			}
+			for i, want := range tt.inPlace {
+				got := diff.inPlace[i]
+				if got.Name != want.Name || got.Type != want.Type ||
+					boolOr(got.Drop, false) != boolOr(want.Drop, false) ||
+					boolOr(got.Facet, false) != boolOr(want.Facet, false) ||
+					boolOr(got.Optional, false) != boolOr(want.Optional, false) {
+					t.Errorf("inPlace[%d] = %s, want %s", i, describeField(got), describeField(want))
+				}
+			}
+		})
+	}
+}
+
+func describeField(field api.Field) string {
+	return field.Name + " " + field.Type +
+		" drop=" + boolString(field.Drop) + " facet=" + boolString(field.Facet) + " optional=" + boolString(field.Optional)
+}
+
+func boolString(value *bool) string {
+	if boolOr(value, false) {
+		return "true"
+	}
+	return "false"
+}